@@ -0,0 +1,38 @@
+package limitedlistener
+
+import (
+	"errors"
+	"net"
+)
+
+// AcceptError is returned by Accept when the underlying listener fails to accept a connection. It carries the
+// address of the listener and implements net.Error by passing Timeout and Temporary through to the underlying
+// error, so servers that back off on temporary accept errors keep doing so; errors.Is and errors.As see the
+// underlying error through Unwrap.
+type AcceptError struct {
+	Addr net.Addr
+	Err  error
+}
+
+func (e *AcceptError) Error() string {
+	if e.Addr == nil {
+		return "accept: " + e.Err.Error()
+	}
+	return "accept on " + e.Addr.String() + ": " + e.Err.Error()
+}
+
+func (e *AcceptError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout reports whether the underlying error is a timeout.
+func (e *AcceptError) Timeout() bool {
+	var netErr net.Error
+	return errors.As(e.Err, &netErr) && netErr.Timeout()
+}
+
+// Temporary reports whether the underlying error is temporary.
+func (e *AcceptError) Temporary() bool {
+	var tempErr interface{ Temporary() bool }
+	return errors.As(e.Err, &tempErr) && tempErr.Temporary()
+}
@@ -0,0 +1,36 @@
+package limitedlistener
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+// TestAcceptError verifies that an accept error of the underlying listener is wrapped with the listener address
+// and still matches the underlying error.
+func TestAcceptError(t *testing.T) {
+	listener, err := Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	addr := listener.Addr()
+	listener.Close()
+
+	_, err = listener.Accept()
+	var acceptErr *AcceptError
+	if !errors.As(err, &acceptErr) {
+		t.Fatalf("expected an *AcceptError, got %T: %v", err, err)
+	}
+	if acceptErr.Addr.String() != addr.String() || !strings.Contains(err.Error(), addr.String()) {
+		t.Errorf("expected the error to carry the listener address %v, got %v", addr, err)
+	}
+	if !errors.Is(err, net.ErrClosed) {
+		t.Errorf("expected the error to match net.ErrClosed, got %v", err)
+	}
+
+	var netErr net.Error
+	if !errors.As(err, &netErr) || netErr.Timeout() {
+		t.Errorf("expected a net.Error that is not a timeout, got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package limitedlistener
+
+import (
+	"net"
+	"time"
+)
+
+// WithAcceptRate limits how many connections per second Accept hands out, allowing bursts of up to burst connections.
+// Connections over the rate are accepted from the kernel but held by Accept until the rate allows them,
+// which is reported by PendingAccepts.
+func WithAcceptRate(perSecond float64, burst int) Option {
+	return func(o *options) {
+		o.acceptRate = perSecond
+		o.acceptBurst = burst
+	}
+}
+
+// PendingAccepts returns the number of connections accepted from the underlying listener that Accept
+// has not returned yet, for example because they are delayed by the accept rate.
+func (l *LimitedListener) PendingAccepts() int {
+	return int(l.pendingAccepts.Load())
+}
+
+// waitAcceptRate blocks until the accept rate allows another connection to be handed out. It returns the context
+// error if the listener's context is cancelled first, or net.ErrClosed if the listener is closed.
+func (l *LimitedListener) waitAcceptRate() error {
+	if l.acceptLimiter == nil {
+		return nil
+	}
+
+	reservation := l.acceptLimiter.Reserve()
+	delay := reservation.Delay()
+	if delay == 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-l.ctx.Done():
+		reservation.Cancel()
+		return l.ctx.Err()
+	case <-l.done:
+		reservation.Cancel()
+		return net.ErrClosed
+	}
+}
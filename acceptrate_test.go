@@ -0,0 +1,99 @@
+package limitedlistener
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestPendingAccepts verifies that connections delayed by the accept rate are reported as pending until Accept returns them.
+func TestPendingAccepts(t *testing.T) {
+	limitedListener, err := Listen("tcp", "127.0.0.1:0", WithAcceptRate(5, 1))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer limitedListener.Close()
+
+	const clients = 3
+
+	for i := 0; i < clients; i++ {
+		client, err := net.Dial("tcp", limitedListener.Addr().String())
+		if err != nil {
+			t.Fatalf("dial error: %v", err)
+		}
+		defer client.Close()
+	}
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn, err := limitedListener.Accept()
+			if err != nil {
+				t.Errorf("accept error: %v", err)
+				return
+			}
+			conn.Close()
+		}()
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if pending := limitedListener.PendingAccepts(); pending != clients-1 {
+		t.Errorf("expected %d pending accepts, but got %d", clients-1, pending)
+	}
+
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 350*time.Millisecond {
+		t.Errorf("expected the accepts to be spread at 5 per second, but they took %v", elapsed)
+	}
+	if pending := limitedListener.PendingAccepts(); pending != 0 {
+		t.Errorf("expected no pending accepts, but got %d", pending)
+	}
+}
+
+// TestAcceptRateClose verifies that closing the listener unblocks an Accept waiting for the accept rate, and that
+// the connection it held is closed without being answered or counted as a rejection.
+func TestAcceptRateClose(t *testing.T) {
+	var responded atomic.Bool
+	listener, err := NewLimitedListener(pipeListener{}, 1_000, 100, WithAcceptRate(0.1, 1),
+		WithRejectResponder(func(net.Conn) { responded.Store(true) }))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	if _, err := listener.Accept(); err != nil {
+		t.Fatalf("accept error: %v", err)
+	}
+
+	acceptErr := make(chan error)
+	go func() {
+		_, err := listener.Accept()
+		acceptErr <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	listener.Close()
+
+	select {
+	case err := <-acceptErr:
+		if !errors.Is(err, net.ErrClosed) {
+			t.Errorf("expected %v, got %v", net.ErrClosed, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected Close to unblock the pending Accept")
+	}
+
+	if responded.Load() {
+		t.Errorf("expected no reject response during shutdown")
+	}
+	for reason, count := range listener.RejectedStats() {
+		if count != 0 {
+			t.Errorf("expected no %s rejection during shutdown, got %d", reason, count)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package limitedlistener
+
+import (
+	"sync"
+	"time"
+)
+
+// acceptIntervalWeight is the weight of the latest interval in the moving average kept for AcceptStats.
+const acceptIntervalWeight = 0.2
+
+// acceptTracker keeps an exponentially weighted moving average of the intervals between the returns of Accept.
+type acceptTracker struct {
+	mu      sync.Mutex
+	last    time.Time
+	average float64
+}
+
+// record accounts a return of Accept at now.
+func (t *acceptTracker) record(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.last.IsZero() {
+		interval := float64(now.Sub(t.last))
+		if t.average == 0 {
+			t.average = interval
+		} else {
+			t.average += acceptIntervalWeight * (interval - t.average)
+		}
+	}
+	t.last = now
+}
+
+// AcceptStats returns the rate at which Accept hands out connections, in connections per second, and the average
+// interval between two of them, both from a moving average favoring the recent intervals. A long interval with
+// connections waiting in the backlog points at a slow accept loop rather than a quiet server. Both are zero until
+// Accept has returned two connections.
+func (l *LimitedListener) AcceptStats() (rate float64, avgInterval time.Duration) {
+	l.acceptTracker.mu.Lock()
+	defer l.acceptTracker.mu.Unlock()
+
+	if l.acceptTracker.average <= 0 {
+		return 0, 0
+	}
+	return float64(time.Second) / l.acceptTracker.average, time.Duration(l.acceptTracker.average)
+}
@@ -0,0 +1,47 @@
+package limitedlistener
+
+import (
+	"math"
+	"net"
+	"testing"
+	"time"
+)
+
+// cadenceListener is a net.Listener handing out a connection every interval.
+type cadenceListener struct {
+	pipeListener
+	interval time.Duration
+}
+
+func (l cadenceListener) Accept() (net.Conn, error) {
+	time.Sleep(l.interval)
+	return l.pipeListener.Accept()
+}
+
+// TestAcceptStats verifies that the accept rate and interval follow the cadence of the incoming connections.
+func TestAcceptStats(t *testing.T) {
+	listener, err := NewLimitedListener(cadenceListener{interval: 50 * time.Millisecond}, 1_000, 100)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	if rate, interval := listener.AcceptStats(); rate != 0 || interval != 0 {
+		t.Errorf("expected no stats before any connection, got %.1f/s and %v", rate, interval)
+	}
+
+	for i := 0; i < 10; i++ {
+		conn, err := listener.Accept()
+		if err != nil {
+			t.Fatalf("didn't expect error but got one: %v", err)
+		}
+		conn.Close()
+	}
+
+	rate, interval := listener.AcceptStats()
+	if math.Abs(rate-20) > 5 {
+		t.Errorf("expected about 20 accepts per second, got %.1f", rate)
+	}
+	if interval < 40*time.Millisecond || interval > 65*time.Millisecond {
+		t.Errorf("expected an average interval of about 50ms, got %v", interval)
+	}
+}
@@ -0,0 +1,21 @@
+package limitedlistener
+
+// WriteAccounted writes b like Write, throttled on the bytes actually written, and records logicalBytes in the
+// separate logical counter returned by LogicalBytesWritten, for example the uncompressed size of a compressed
+// response. Comparing both counters gives the wire and the logical throughput. On a short write only the share
+// of logicalBytes matching the written part of b is recorded.
+func (lc *LimitedConnection) WriteAccounted(b []byte, logicalBytes int) (int, error) {
+	n, err := lc.Write(b)
+	switch {
+	case n == len(b):
+		addSaturating(&lc.logicalBytesWritten, int64(logicalBytes))
+	case n > 0:
+		addSaturating(&lc.logicalBytesWritten, int64(logicalBytes)*int64(n)/int64(len(b)))
+	}
+	return n, err
+}
+
+// LogicalBytesWritten returns the logical bytes recorded by WriteAccounted so far.
+func (lc *LimitedConnection) LogicalBytesWritten() int64 {
+	return lc.logicalBytesWritten.Load()
+}
@@ -0,0 +1,45 @@
+package limitedlistener
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestWriteAccounted verifies that the wire and logical counters diverge and that writes are throttled on the wire size.
+func TestWriteAccounted(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+	go io.Copy(io.Discard, client)
+
+	listener, err := NewLimitedListener(nil, 1_000, 1_000, WithGlobalWriteLimit(1_000))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	start := time.Now()
+	if _, err := lc.WriteAccounted(make([]byte, 1_000), 8_000); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if _, err := lc.WriteAccounted(make([]byte, 500), 4_000); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	// 1500 wire bytes at 1000 bytes per second after the 1000-byte burst; the 12000 logical bytes aren't charged.
+	if elapsed := time.Since(start); elapsed < 450*time.Millisecond || elapsed > time.Second {
+		t.Errorf("expected the writes to be throttled on their wire size, but they took %v", elapsed)
+	}
+
+	if got := lc.BytesWritten(); got != 1_500 {
+		t.Errorf("expected 1500 wire bytes, got %d", got)
+	}
+	if got := lc.LogicalBytesWritten(); got != 12_000 {
+		t.Errorf("expected 12000 logical bytes, got %d", got)
+	}
+	if got := lc.Snapshot(); got.BytesWritten != 1_500 || got.LogicalBytesWritten != 12_000 {
+		t.Errorf("expected the snapshot to report both counters, got %+v", got)
+	}
+}
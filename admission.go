@@ -0,0 +1,50 @@
+package limitedlistener
+
+import "time"
+
+const (
+	// admissionInitialBackoff is the first delay before asking a refusing admission controller again.
+	admissionInitialBackoff = 10 * time.Millisecond
+	// admissionMaxBackoff caps the delay between two checks of the admission controller.
+	admissionMaxBackoff = 200 * time.Millisecond
+	// admissionTimeout is how long Accept holds a connection back before closing it if the controller keeps refusing.
+	admissionTimeout = time.Second
+)
+
+// WithAdmissionController makes Accept ask admit before handing out each accepted connection, so the accept loop
+// slows down based on a live health signal instead of a fixed rate. While admit returns false the connection is
+// held back and admit is asked again with an exponential backoff; if it still refuses after admissionTimeout, the
+// connection is closed, through the reject responder if one is set, and Accept waits for the next one.
+func WithAdmissionController(admit func() bool) Option {
+	return func(o *options) {
+		o.admissionController = admit
+	}
+}
+
+// waitAdmission blocks until the admission controller admits a new connection. It returns false if it kept
+// refusing for admissionTimeout or the listener was closed in the meantime.
+func (l *LimitedListener) waitAdmission() bool {
+	admit := l.opts.admissionController
+	if admit == nil || admit() {
+		return true
+	}
+
+	clock := l.opts.clock
+	deadline := clock.Now().Add(admissionTimeout)
+	for backoff := admissionInitialBackoff; ; backoff = min(2*backoff, admissionMaxBackoff) {
+		wait := min(backoff, deadline.Sub(clock.Now()))
+		if wait <= 0 {
+			return false
+		}
+		select {
+		case <-clock.After(wait):
+		case <-l.done:
+			return false
+		case <-l.ctx.Done():
+			return false
+		}
+		if admit() {
+			return true
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package limitedlistener
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestAdmissionController verifies that Accept stops returning connections while the admission controller
+// refuses them and resumes as soon as it admits them again.
+func TestAdmissionController(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+
+	listener, err := NewLimitedListener(pipeListener{}, 1_000, 1_000, WithAdmissionController(healthy.Load))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer listener.Close()
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("expected a connection to be accepted while healthy, got %v", err)
+	}
+	conn.Close()
+
+	healthy.Store(false)
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+
+	select {
+	case conn := <-accepted:
+		conn.Close()
+		t.Fatalf("expected no connection to be accepted while the controller refuses them")
+	case <-time.After(admissionTimeout + 200*time.Millisecond):
+	}
+	if got := listener.RejectedStats()["admission"]; got == 0 {
+		t.Errorf("expected the connections held back for too long to be rejected, got %d rejections", got)
+	}
+
+	healthy.Store(true)
+	select {
+	case conn := <-accepted:
+		conn.Close()
+	case <-time.After(time.Second):
+		t.Fatalf("expected Accept to return a connection once the controller admits them again")
+	}
+}
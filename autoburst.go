@@ -0,0 +1,42 @@
+package limitedlistener
+
+const (
+	// autoBurstWeight is the weight of the latest buffer size in the moving average kept by WithAutoBurst.
+	autoBurstWeight = 0.2
+	// autoBurstMaxFactor bounds the burst chosen by WithAutoBurst to this many seconds of the per-connection limit.
+	autoBurstMaxFactor = 4
+)
+
+// WithAutoBurst adapts the burst of every per-connection limiter to the size of the buffers the caller passes to
+// Read, tracked as an exponentially weighted moving average. Reads are capped at the burst, so a caller reading
+// with buffers larger than one second of its limit would otherwise get its reads chopped into many small ones.
+// The burst never drops below the per-connection limit and never exceeds autoBurstMaxFactor times it, and the
+// limit itself is unchanged, so the long-run rate stays the same.
+func WithAutoBurst() Option {
+	return func(o *options) {
+		o.autoBurst = true
+	}
+}
+
+// observeReadSize folds the size of a buffer passed to Read into the moving average and adapts the burst of the
+// per-connection limiter to it.
+func (lc *LimitedConnection) observeReadSize(size int) {
+	limiter := lc.perConnLimiter()
+
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	if lc.readSizeAverage == 0 {
+		lc.readSizeAverage = float64(size)
+	} else {
+		lc.readSizeAverage += autoBurstWeight * (float64(size) - lc.readSizeAverage)
+	}
+
+	if lc.bytesPerSecond <= 0 {
+		return
+	}
+	burst := min(max(int(lc.readSizeAverage), lc.bytesPerSecond), autoBurstMaxFactor*lc.bytesPerSecond)
+	if burst != limiter.Burst() {
+		limiter.SetBurst(burst)
+	}
+}
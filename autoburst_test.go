@@ -0,0 +1,57 @@
+package limitedlistener
+
+import (
+	"net"
+	"testing"
+)
+
+// TestAutoBurst verifies that the per-connection burst follows the buffer sizes passed to Read, within its bounds.
+func TestAutoBurst(t *testing.T) {
+	testCases := []struct {
+		test      string
+		bufSize   int
+		wantBurst int
+	}{
+		{"64KB buffers should raise the burst to 64KB", 64 << 10, 64 << 10},
+		{"Small buffers should keep the burst at the limit", 1 << 10, 16 << 10},
+		{"Huge buffers should raise the burst to its upper bound", 1 << 20, autoBurstMaxFactor * 16 << 10},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.test, func(t *testing.T) {
+			server, client := net.Pipe()
+			defer client.Close()
+
+			listener, err := NewLimitedListener(nil, 1<<20, 16<<10, WithAutoBurst())
+			if err != nil {
+				t.Fatalf("didn't expect error but got one: %v", err)
+			}
+
+			lc := admitConn(t, listener, server)
+			defer lc.Close()
+			if got := lc.Limiter().Burst(); got != 16<<10 {
+				t.Fatalf("expected the burst to start at the limit, got %d", got)
+			}
+
+			// Every read only gets a single byte, so the test doesn't wait on the limiter while the burst adapts.
+			go func() {
+				for i := 0; i < 10; i++ {
+					client.Write([]byte{0})
+				}
+			}()
+			buf := make([]byte, tc.bufSize)
+			for i := 0; i < 10; i++ {
+				if _, err := lc.Read(buf); err != nil {
+					t.Fatalf("read error: %v", err)
+				}
+			}
+
+			if got := lc.Limiter().Burst(); got != tc.wantBurst {
+				t.Errorf("expected a burst of %d, got %d", tc.wantBurst, got)
+			}
+			if got := lc.Limiter().Limit(); got != 16<<10 {
+				t.Errorf("expected the limit to stay at %d, got %v", 16<<10, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package limitedlistener
+
+// WithGlobalBitsPerSecond sets the global bandwidth limit in bits per second, for limits quoted the way network
+// links are, such as 100 Mbps. It replaces the global limit set by WithLimits, see there for when it applies.
+// The limit is converted to bytes per second by dividing by 8 and rounding down, with a floor of 1 byte per
+// second for positive values, so 100_000_000 bits per second become 12_500_000 bytes per second.
+func WithGlobalBitsPerSecond(bitsPerSecond int64) Option {
+	return func(o *options) {
+		o.globalLimit = bitsToBytes(bitsPerSecond)
+	}
+}
+
+// WithPerConnBitsPerSecond sets the per-connection bandwidth limit in bits per second, converted to bytes per
+// second like WithGlobalBitsPerSecond. It replaces the per-connection limit set by WithLimits.
+func WithPerConnBitsPerSecond(bitsPerSecond int64) Option {
+	return func(o *options) {
+		o.perConnLimit = bitsToBytes(bitsPerSecond)
+	}
+}
+
+// bitsToBytes converts a rate in bits per second to bytes per second, rounding down with a floor of 1 for
+// positive rates. Other values are passed through so validation rejects them.
+func bitsToBytes(bitsPerSecond int64) int {
+	if bitsPerSecond <= 0 {
+		return int(bitsPerSecond)
+	}
+	return int(max(bitsPerSecond/8, 1))
+}
@@ -0,0 +1,81 @@
+package limitedlistener
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestBitsPerSecondConversion verifies how bit rates are converted to the byte rates used internally.
+func TestBitsPerSecondConversion(t *testing.T) {
+	testCases := []struct {
+		test string
+		bits int64
+		want int
+	}{
+		{"8 bits per second should be 1 byte per second", 8, 1},
+		{"Non-multiples of 8 should round down", 8_007, 1_000},
+		{"Rates under a byte per second should be raised to 1", 7, 1},
+		{"100 Mbps should be 12.5 MB per second", 100_000_000, 12_500_000},
+		{"Zero should stay unlimited", 0, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.test, func(t *testing.T) {
+			o := newOptions([]Option{WithGlobalBitsPerSecond(tc.bits), WithPerConnBitsPerSecond(tc.bits)})
+			if o.globalLimit != tc.want || o.perConnLimit != tc.want {
+				t.Errorf("expected %d bytes per second, got global %d, perConn %d", tc.want, o.globalLimit, o.perConnLimit)
+			}
+		})
+	}
+
+	if _, err := Listen("tcp", "127.0.0.1:0", WithGlobalBitsPerSecond(-8), WithPerConnBitsPerSecond(8)); !errors.Is(err, ErrLimitOutOfRange) {
+		t.Errorf("expected ErrLimitOutOfRange for a negative bit rate, got %v", err)
+	}
+}
+
+// TestBitsPerSecondThroughput verifies that a connection is throttled to the byte rate matching the bit rate.
+func TestBitsPerSecondThroughput(t *testing.T) {
+	listener, err := Listen("tcp", "127.0.0.1:0", WithGlobalBitsPerSecond(80_000), WithPerConnBitsPerSecond(8_000))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer listener.Close()
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	defer client.Close()
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("accept error: %v", err)
+	}
+	defer conn.Close()
+
+	go client.Write(make([]byte, 1_500))
+
+	start := time.Now()
+	if _, err := io.ReadFull(conn, make([]byte, 1_500)); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	// The 1000-byte burst is read at once, the remaining 500 bytes take half a second at 1000 bytes per second.
+	if elapsed := time.Since(start); elapsed < 450*time.Millisecond || elapsed > time.Second {
+		t.Errorf("expected the transfer to take about 500ms at 8000 bits per second, but it took %v", elapsed)
+	}
+}
+
+// TestBitsPerSecondOverridesPositionalLimits verifies that the bit rate options replace the limits passed to
+// NewLimitedListener.
+func TestBitsPerSecondOverridesPositionalLimits(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 1_000, 100, WithPerConnBitsPerSecond(400))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	if got := listener.ExportConfig(); got.GlobalLimit != 1_000 || got.PerConnLimit != 50 {
+		t.Errorf("expected limits of 1000 and 50, got %d and %d", got.GlobalLimit, got.PerConnLimit)
+	}
+}
@@ -0,0 +1,92 @@
+package limitedlistener
+
+import (
+	"context"
+	"math"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// WithPerConnIntervalBudget caps the bytes every connection may read to bytes per interval, for example 10MB per
+// minute, on top of the bandwidth limits. Within a window a connection may read at its full rate until the budget
+// is spent; its reads then block until the window ends and the budget is refilled. Windows start with the first
+// read of a connection. Reads are shrunk to the budget left, so concurrent reads on the same connection are the
+// only way to overshoot it. Connections exempted with WithLimitPredicate have no budget.
+func WithPerConnIntervalBudget(bytes int64, interval time.Duration) Option {
+	return func(o *options) {
+		o.intervalBudget = bytes
+		o.budgetInterval = interval
+	}
+}
+
+// intervalBudget tracks the bytes a connection has read in the current budget window.
+type intervalBudget struct {
+	mu          sync.Mutex
+	windowStart time.Time
+	used        int64
+}
+
+// remainingBudget returns the bytes the connection may still read in the current window, starting a new window
+// if the previous one has ended, and when the window ends. Without a budget it returns math.MaxInt64.
+func (lc *LimitedConnection) remainingBudget(now time.Time) (int64, time.Time) {
+	o := lc.options()
+	if o.intervalBudget <= 0 || o.budgetInterval <= 0 || lc.unlimited {
+		return math.MaxInt64, time.Time{}
+	}
+
+	b := &lc.budget
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.windowStart.IsZero() || now.Sub(b.windowStart) >= o.budgetInterval {
+		b.windowStart = now
+		b.used = 0
+	}
+	return max(o.intervalBudget-b.used, 0), b.windowStart.Add(o.budgetInterval)
+}
+
+// waitBudget blocks until the connection has budget left in its window and returns how much. It returns
+// os.ErrDeadlineExceeded once the read deadline passes, net.ErrClosed if the connection is closed, or the
+// context error.
+func (lc *LimitedConnection) waitBudget(ctx context.Context) (int64, error) {
+	remaining, refill := lc.remainingBudget(time.Now())
+	if remaining > 0 {
+		return remaining, nil
+	}
+
+	var timeout <-chan time.Time
+	if d := lc.readDeadline.Load(); d != 0 {
+		deadline := time.NewTimer(time.Until(time.Unix(0, d)))
+		defer deadline.Stop()
+		timeout = deadline.C
+	}
+
+	for remaining == 0 {
+		timer := time.NewTimer(time.Until(refill))
+		select {
+		case <-timer.C:
+		case <-lc.closing:
+			timer.Stop()
+			return 0, net.ErrClosed
+		case <-ctx.Done():
+			timer.Stop()
+			return 0, ctx.Err()
+		case <-timeout:
+			timer.Stop()
+			return 0, os.ErrDeadlineExceeded
+		}
+		remaining, refill = lc.remainingBudget(time.Now())
+	}
+	return remaining, nil
+}
+
+// spendBudget charges n read bytes to the current budget window.
+func (lc *LimitedConnection) spendBudget(n int) {
+	if lc.options().intervalBudget <= 0 {
+		return
+	}
+	lc.budget.mu.Lock()
+	lc.budget.used += int64(n)
+	lc.budget.mu.Unlock()
+}
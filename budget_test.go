@@ -0,0 +1,79 @@
+package limitedlistener
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestPerConnIntervalBudget verifies that a connection reads up to its budget at full speed and then stalls
+// until the window is refilled.
+func TestPerConnIntervalBudget(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 1_000_000, 1_000_000, WithPerConnIntervalBudget(1_000, 500*time.Millisecond))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	go client.Write(make([]byte, 2_000))
+
+	start := time.Now()
+	buf := make([]byte, 300)
+	received := 0
+	for received < 1_000 {
+		n, err := lc.Read(buf)
+		if err != nil {
+			t.Fatalf("read error: %v", err)
+		}
+		received += n
+	}
+	if received != 1_000 {
+		t.Errorf("expected the reads to stop at the budget of 1000 bytes, got %d", received)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("expected the budget to be read at full speed, took %v", elapsed)
+	}
+
+	if n, err := lc.TryRead(buf); n != 0 || !errors.Is(err, ErrWouldThrottle) {
+		t.Errorf("expected TryRead to report the spent budget with ErrWouldThrottle, got (%d, %v)", n, err)
+	}
+
+	if _, err := lc.Read(buf); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 450*time.Millisecond || elapsed > 700*time.Millisecond {
+		t.Errorf("expected the read after the spent budget to wait for the refill after 500ms, took %v", elapsed)
+	}
+}
+
+// TestPerConnIntervalBudgetDeadline verifies that a read waiting for the refill honors the read deadline.
+func TestPerConnIntervalBudgetDeadline(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 1_000_000, 1_000_000, WithPerConnIntervalBudget(100, time.Minute))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	go client.Write(make([]byte, 200))
+
+	if n, err := lc.Read(make([]byte, 200)); n != 100 || err != nil {
+		t.Fatalf("expected the first read to be cut to the budget of 100 bytes, got (%d, %v)", n, err)
+	}
+
+	lc.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if _, err := lc.Read(make([]byte, 100)); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("expected the deadline to end the wait for the refill, got %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package limitedlistener
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// WriteBuffers writes the contents of bufs to the connection while respecting the global write bandwidth limit.
+// The total size is charged to the limiter up front and the buffers are then written in one call, which uses
+// writev when the underlying connection supports it, instead of waiting on the limiter for every buffer.
+// The caller's bufs is not modified.
+func (lc *LimitedConnection) WriteBuffers(bufs net.Buffers) (int64, error) {
+	if err := lc.checkConnQuota(); err != nil {
+		return 0, err
+	}
+
+	if _, ok := lc.warmupRemaining(); ok {
+		var written int64
+		for _, b := range bufs {
+			n, err := lc.Write(b)
+			written += int64(n)
+			if err != nil {
+				return written, err
+			}
+		}
+		return written, nil
+	}
+
+	var total int
+	for _, b := range bufs {
+		total += len(b)
+	}
+
+	ctx := lc.context()
+	if err := lc.waitResumed(ctx, &lc.writeDeadline); err != nil {
+		return 0, err
+	}
+
+	if !lc.measureOnly() {
+		var buf [2]*rate.Limiter
+		limiters := lc.writeLimiters(buf[:0])
+		charge := lc.options().tokens(total)
+		start := time.Now()
+		var err error
+		for i := 0; i < len(limiters) && err == nil; i++ {
+			if err = waitN(ctx, limiters[i], charge); err != nil {
+				err = fmt.Errorf("%s: %w", writeLimiterName(i), err)
+			}
+		}
+		lc.observeWait(ctx, "write", total, lc.recordWait(start))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	bufs = append(net.Buffers(nil), bufs...)
+	n, err := bufs.WriteTo(lc.Conn)
+	lc.recordWritten(int(n))
+	return n, err
+}
+
+// ReadFrom implements io.ReaderFrom. When the underlying connection has a ReadFrom, r is handed to it so
+// optimizations like sendfile or splice keep working: as is without a global write limit or in measure-only mode,
+// and otherwise in burst-sized chunks, each charged to the write limiters before it is sent. A file served through
+// an *io.LimitedReader, as net/http does, keeps the kernel fast path in the chunked mode too. Without an underlying
+// ReadFrom, r is copied through Write, honoring the limit, using a pooled buffer.
+func (lc *LimitedConnection) ReadFrom(r io.Reader) (int64, error) {
+	if rf, ok := lc.Conn.(io.ReaderFrom); ok {
+		if _, warmingUp := lc.warmupRemaining(); !warmingUp {
+			if (lc.writesUnlimited() || lc.measureOnly()) && !lc.paused() {
+				n, err := rf.ReadFrom(r)
+				lc.recordWritten(int(n))
+				return n, err
+			}
+			if !lc.measureOnly() {
+				return lc.readFromChunked(rf, r)
+			}
+		}
+	}
+
+	// The copy buffer is sized to the write burst, so every chunk read from r is written with a single wait.
+	pooled, buf := getCopyBuffer(lc.globalWriteLimiter.Load().Burst())
+	defer putCopyBuffer(pooled)
+	return io.CopyBuffer(writerOnly{lc}, r, buf)
+}
+
+// readFromChunked copies r into the connection through rf in chunks sized to the write limiters, waiting on the
+// limiters before every chunk, until r is exhausted. Unless r is an *io.LimitedReader, whose size is known, the
+// last chunk is charged in full even if r ends before it.
+func (lc *LimitedConnection) readFromChunked(rf io.ReaderFrom, r io.Reader) (int64, error) {
+	if err := lc.checkConnQuota(); err != nil {
+		return 0, err
+	}
+
+	// The underlying ReadFrom only recognizes a file behind a single *io.LimitedReader, so a limited reader passed
+	// in is unwrapped and its remaining size tracked here rather than nesting another one around it.
+	remaining := int64(-1)
+	if lr, ok := r.(*io.LimitedReader); ok {
+		r, remaining = lr.R, max(lr.N, 0)
+		defer func() { lr.N = remaining }()
+	}
+
+	ctx := lc.context()
+	var buf [2]*rate.Limiter
+	limiters := lc.writeLimiters(buf[:0])
+	opts := lc.options()
+
+	var written int64
+	var waited time.Duration
+	defer func() { lc.observeWait(ctx, "write", int(written), waited) }()
+
+	for remaining != 0 {
+		if err := lc.waitResumed(ctx, &lc.writeDeadline); err != nil {
+			return written, err
+		}
+
+		chunk := math.MaxInt
+		if remaining > 0 {
+			chunk = int(min(int64(chunk), remaining))
+		}
+		for _, limiter := range limiters {
+			chunk = max(opts.fitWrite(maxChunk(limiter, math.MaxInt), chunk), 1)
+		}
+
+		charge := opts.tokens(chunk)
+		start := time.Now()
+		var werr error
+		for i := 0; i < len(limiters) && werr == nil; i++ {
+			if werr = lc.withWaitPolicy(ctx, func() error { return waitN(ctx, limiters[i], charge) }); werr != nil {
+				werr = fmt.Errorf("%s: %w", writeLimiterName(i), werr)
+			}
+		}
+		waited += lc.recordWait(start)
+		if werr != nil {
+			return written, werr
+		}
+
+		n, err := rf.ReadFrom(&io.LimitedReader{R: r, N: int64(chunk)})
+		lc.recordWritten(int(n))
+		written += n
+		if remaining > 0 {
+			remaining -= n
+		}
+		if err != nil || n < int64(chunk) {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// writerOnly hides every method but Write, so io.Copy doesn't call back into LimitedConnection.ReadFrom.
+type writerOnly struct {
+	io.Writer
+}
@@ -0,0 +1,137 @@
+package limitedlistener
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestWriteBuffers verifies that WriteBuffers writes every buffer, counts the bytes and obeys the write limit.
+func TestWriteBuffers(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 1_000, 1_000, WithGlobalWriteLimit(1_000))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	listener.globalWriteLimiter.ReserveN(time.Now(), 1_000)
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	received := make(chan []byte, 1)
+	go func() {
+		data, _ := io.ReadAll(io.LimitReader(client, 500))
+		received <- data
+	}()
+
+	bufs := net.Buffers{bytes.Repeat([]byte("a"), 100), bytes.Repeat([]byte("b"), 150), bytes.Repeat([]byte("c"), 250)}
+	start := time.Now()
+	n, err := lc.WriteBuffers(bufs)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	if n != 500 || lc.BytesWritten() != 500 {
+		t.Errorf("expected 500 bytes written, got %d (counter %d)", n, lc.BytesWritten())
+	}
+	if elapsed := time.Since(start); elapsed < 450*time.Millisecond {
+		t.Errorf("expected 500 bytes at 1000 B/s to take about 500ms, took %v", elapsed)
+	}
+	if data := <-received; !bytes.Equal(data, bytes.Join(bufs, nil)) {
+		t.Errorf("expected the buffers to be written in order, got %q", data)
+	}
+	if len(bufs[0]) != 100 {
+		t.Errorf("expected the caller's buffers to be left untouched")
+	}
+}
+
+// TestReadFrom verifies that io.Copy into a connection goes through the write limit.
+func TestReadFrom(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 1_000, 1_000, WithGlobalWriteLimit(1_000))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	go io.Copy(io.Discard, client)
+
+	start := time.Now()
+	n, err := io.Copy(lc, bytes.NewReader(make([]byte, 1_500)))
+	if err != nil || n != 1_500 {
+		t.Fatalf("expected (1500, nil), got (%d, %v)", n, err)
+	}
+	if elapsed := time.Since(start); elapsed < 450*time.Millisecond {
+		t.Errorf("expected the bytes over the burst to be throttled, took %v", elapsed)
+	}
+}
+
+// discardConn is a net.Conn whose writes succeed without doing anything.
+type discardConn struct {
+	net.Conn
+}
+
+func (discardConn) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+// BenchmarkReadFrom measures copying 64 KiB into a connection through ReadFrom.
+func BenchmarkReadFrom(b *testing.B) {
+	lc := WrapStreamConn(discardConn{}, nil, 0)
+	src := make([]byte, 64<<10)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := lc.ReadFrom(io.LimitReader(bytes.NewReader(src), int64(len(src)))); err != nil {
+			b.Fatalf("didn't expect error but got one: %v", err)
+		}
+	}
+}
+
+// TestServeContentThrottled verifies that a file served with http.ServeContent, which goes through ReadFrom and
+// sendfile, is sent at the global write limit.
+func TestServeContentThrottled(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), "content")
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(make([]byte, 150_000)); err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	listener, err := Listen("tcp", "127.0.0.1:0", WithGlobalWriteLimit(50_000))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "content.bin", time.Time{}, file)
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	start := time.Now()
+	resp, err := http.Get("http://" + listener.Addr().String())
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer resp.Body.Close()
+	n, err := io.Copy(io.Discard, resp.Body)
+	if err != nil || n != 150_000 {
+		t.Fatalf("expected the whole file, got %d bytes and %v", n, err)
+	}
+
+	// 150000 bytes at 50000 bytes per second, the first 50000 covered by the burst.
+	if elapsed := time.Since(start); elapsed < 1_800*time.Millisecond || elapsed > 3*time.Second {
+		t.Errorf("expected the file to be served in about 2s, took %v", elapsed)
+	}
+}
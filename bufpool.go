@@ -0,0 +1,30 @@
+package limitedlistener
+
+import "sync"
+
+// copyBufferSize is the size of the buffers used by the internal copy loops, matching io.Copy.
+const copyBufferSize = 32 << 10
+
+// copyBuffers pools the buffers of the internal copy loops, so thousands of concurrent transfers don't
+// allocate a buffer per operation.
+var copyBuffers = sync.Pool{
+	New: func() any {
+		buf := make([]byte, copyBufferSize)
+		return &buf
+	},
+}
+
+// getCopyBuffer returns a pooled buffer of at most size bytes, or of copyBufferSize if size is not positive.
+// It must be handed back with putCopyBuffer.
+func getCopyBuffer(size int) (*[]byte, []byte) {
+	pooled := copyBuffers.Get().(*[]byte)
+	if size <= 0 || size > len(*pooled) {
+		return pooled, *pooled
+	}
+	return pooled, (*pooled)[:size]
+}
+
+// putCopyBuffer returns a buffer obtained from getCopyBuffer to the pool.
+func putCopyBuffer(pooled *[]byte) {
+	copyBuffers.Put(pooled)
+}
@@ -0,0 +1,26 @@
+package limitedlistener
+
+// GrantBurst gives the connection a one-time credit of extraBytes on top of its per-connection limit, for example
+// when a user asks for an interactive download. Reads draw on the credit before waiting on the per-connection
+// limiter, so the connection briefly runs faster and then settles back to its steady rate once the credit is
+// spent. The global and other limiters still apply. Credits add up; non-positive values are ignored.
+func (lc *LimitedConnection) GrantBurst(extraBytes int) {
+	if extraBytes <= 0 {
+		return
+	}
+	addSaturating(&lc.burstCredit, int64(extraBytes))
+}
+
+// takeBurstCredit consumes up to n bytes of the credit granted with GrantBurst and returns how many it consumed.
+func (lc *LimitedConnection) takeBurstCredit(n int) int {
+	for {
+		credit := lc.burstCredit.Load()
+		if credit <= 0 {
+			return 0
+		}
+		taken := min(credit, int64(n))
+		if lc.burstCredit.CompareAndSwap(credit, credit-taken) {
+			return int(taken)
+		}
+	}
+}
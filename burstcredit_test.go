@@ -0,0 +1,46 @@
+package limitedlistener
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestGrantBurst verifies that a granted burst is read faster than the steady rate, which applies again once
+// the credit is spent.
+func TestGrantBurst(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 100_000, 1_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	lc.GrantBurst(2_000)
+	if got := lc.AvailableTokens(); got != 3_000 {
+		t.Errorf("expected the burst plus the credit to be available, got %v", got)
+	}
+
+	go client.Write(make([]byte, 3_500))
+
+	start := time.Now()
+	if _, err := io.ReadFull(lc, make([]byte, 3_000)); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("expected the burst and the credit to be read at once, but it took %v", elapsed)
+	}
+
+	start = time.Now()
+	if _, err := io.ReadFull(lc, make([]byte, 500)); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 450*time.Millisecond {
+		t.Errorf("expected the steady rate to apply once the credit is spent, but it took %v", elapsed)
+	}
+}
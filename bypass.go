@@ -0,0 +1,35 @@
+package limitedlistener
+
+import "golang.org/x/time/rate"
+
+// WithBypassWrapperWhenUnlimited makes Accept return the connection of the underlying listener as is, instead of
+// wrapping it in a LimitedConnection, while limiting is fully disabled: no global read, global write or
+// per-connection limit, not paused and not in measure-only mode, and no option acting on individual connections,
+// such as byte quotas and budgets, tenants, a priority prefix, rate decay, timeouts, read observers and guards, a
+// maximum number of connections or an idle callback. Reads and writes then cost nothing over the raw connection.
+//
+// The decision is made once per connection at accept time. A bypassed connection is not tracked by the listener:
+// it is not counted in Stats, keeps running unthrottled if limits are set later and is not closed by Shutdown.
+// Keep the default, which always wraps, if limits may be turned on while connections are open.
+func WithBypassWrapperWhenUnlimited() Option {
+	return func(o *options) {
+		o.bypassWrapperWhenUnlimited = true
+	}
+}
+
+// perConnFeatures reports whether any option needs connections to be wrapped or tracked, regardless of the limits.
+func (o *options) perConnFeatures() bool {
+	return o.totalByteQuota > 0 || o.perConnByteQuota > 0 || (o.intervalBudget > 0 && o.budgetInterval > 0) ||
+		o.tenantOf != nil || o.priorityLength > 0 || o.rateDecay != nil || o.firstByteTimeout > 0 ||
+		o.operationTimeout > 0 || o.readObserver != nil || o.maxConcurrentReads > 0 || o.readGuard ||
+		o.maxConnections > 0 || o.onIdle != nil
+}
+
+// bypassWrapperLocked reports whether accepted connections should be handed out without a LimitedConnection
+// wrapper. The caller must hold mu.
+func (l *LimitedListener) bypassWrapperLocked() bool {
+	if !l.opts.bypassWrapperWhenUnlimited || l.opts.perConnFeatures() || l.measureOnly.Load() || l.IsPaused() {
+		return false
+	}
+	return l.globalReadLimiter.Limit() == rate.Inf && l.globalWriteLimiter.Limit() == rate.Inf && l.perConnBandwidthLimit == 0
+}
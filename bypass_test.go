@@ -0,0 +1,132 @@
+package limitedlistener
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// zeroConn is a net.Conn whose reads fill the buffer with zeros and whose writes succeed without doing anything.
+type zeroConn struct {
+	net.Conn
+}
+
+func (zeroConn) Read(b []byte) (int, error) {
+	clear(b)
+	return len(b), nil
+}
+
+func (zeroConn) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (zeroConn) Close() error {
+	return nil
+}
+
+// zeroListener is a net.Listener handing out zeroConn connections.
+type zeroListener struct {
+	pipeListener
+}
+
+func (zeroListener) Accept() (net.Conn, error) {
+	return zeroConn{}, nil
+}
+
+// TestBypassWrapperWhenUnlimited verifies that connections are only handed out unwrapped while nothing is limited.
+func TestBypassWrapperWhenUnlimited(t *testing.T) {
+	unlimited, err := newLimitedListener(zeroListener{}, newOptions([]Option{WithBypassWrapperWhenUnlimited()}))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	conn, err := unlimited.Accept()
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	if _, ok := conn.(zeroConn); !ok {
+		t.Errorf("expected the raw connection, got %T", conn)
+	}
+	if got := len(unlimited.trackedConnections()); got != 0 {
+		t.Errorf("expected the bypassed connection not to be tracked, got %d", got)
+	}
+
+	unlimited.Pause()
+	conn, err = unlimited.Accept()
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	if _, ok := conn.(*LimitedConnection); !ok {
+		t.Errorf("expected a wrapped connection while paused, got %T", conn)
+	}
+	unlimited.Resume()
+
+	limited, err := NewLimitedListener(zeroListener{}, 1_000, 100, WithBypassWrapperWhenUnlimited())
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	conn, err = limited.Accept()
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	if _, ok := conn.(*LimitedConnection); !ok {
+		t.Errorf("expected a wrapped connection with limits set, got %T", conn)
+	}
+}
+
+// TestBypassWrapperPerConnOptions verifies that options acting on individual connections keep them wrapped and
+// tracked even while nothing is limited.
+func TestBypassWrapperPerConnOptions(t *testing.T) {
+	for name, opt := range map[string]Option{
+		"max connections":   WithMaxConnections(1),
+		"first byte":        WithFirstByteTimeout(time.Second),
+		"interval budget":   WithPerConnIntervalBudget(10, time.Second),
+		"operation timeout": WithOperationTimeout(time.Second),
+		"idle callback":     WithOnIdle(func() {}),
+	} {
+		listener, err := newLimitedListener(zeroListener{}, newOptions([]Option{WithBypassWrapperWhenUnlimited(), opt}))
+		if err != nil {
+			t.Fatalf("%s: didn't expect error but got one: %v", name, err)
+		}
+		conn, err := listener.Accept()
+		if err != nil {
+			t.Fatalf("%s: didn't expect error but got one: %v", name, err)
+		}
+		if _, ok := conn.(*LimitedConnection); !ok {
+			t.Errorf("%s: expected a wrapped connection, got %T", name, conn)
+		}
+		conn.Close()
+	}
+}
+
+// BenchmarkUnlimitedRead compares the read throughput of an unlimited connection wrapped in a LimitedConnection
+// against one handed out unwrapped with WithBypassWrapperWhenUnlimited.
+func BenchmarkUnlimitedRead(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"wrapped", nil},
+		{"bypassed", []Option{WithBypassWrapperWhenUnlimited()}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			listener, err := newLimitedListener(zeroListener{}, newOptions(bc.opts))
+			if err != nil {
+				b.Fatalf("didn't expect error but got one: %v", err)
+			}
+			conn, err := listener.Accept()
+			if err != nil {
+				b.Fatalf("didn't expect error but got one: %v", err)
+			}
+			buf := make([]byte, 32<<10)
+
+			b.SetBytes(int64(len(buf)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := conn.Read(buf); err != nil {
+					b.Fatalf("didn't expect error but got one: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package limitedlistener
+
+// WithClampPerConn clamps a per-connection limit higher than the global limit to the global limit instead of
+// rejecting it, so callers scaling the two limits independently don't see their changes silently ignored.
+// It applies to the limits passed to the constructors, SetLimits, SetLimitsAt and ScaleLimits. Other invalid
+// limits, such as non-positive ones, are still rejected. WithPerConnPercent takes precedence over it.
+func WithClampPerConn() Option {
+	return func(o *options) {
+		o.clampPerConn = true
+	}
+}
@@ -0,0 +1,31 @@
+package limitedlistener
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestClampPerConn verifies that a per-connection limit above the global one is clamped instead of rejected.
+func TestClampPerConn(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 10_000, 20_000, WithClampPerConn())
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	if got := listener.Stats().PerConnLimit; got != 10_000 {
+		t.Errorf("expected the initial per-connection limit to be clamped to 10000, got %d", got)
+	}
+
+	listener.SetLimits(5_000, 8_000)
+	if got := listener.Stats(); got.GlobalLimit != 5_000 || got.PerConnLimit != 5_000 {
+		t.Errorf("expected the per-connection limit to be clamped to the global one, got %+v", got)
+	}
+
+	listener.SetLimits(0, 1_000)
+	if got := listener.Stats().GlobalLimit; got != 5_000 {
+		t.Errorf("expected non-positive limits to still be rejected, got a global limit of %d", got)
+	}
+
+	if _, err := NewLimitedListener(nil, 10_000, 20_000); !errors.Is(err, ErrInvalidLimits) {
+		t.Errorf("expected ErrInvalidLimits without clamping, got %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package limitedlistener
+
+import "time"
+
+// Clock tells the time to the time-based features of a listener, such as WithSchedule. It can be replaced with
+// WithClock, for example by a fake clock in tests.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the Clock backed by the time package.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// WithClock replaces the clock used by the time-based features of the listener. The limiters themselves keep
+// using the time package.
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
@@ -0,0 +1,55 @@
+package limitedlistener
+
+import (
+	"net"
+
+	"golang.org/x/time/rate"
+)
+
+// WithGlobalLimiter makes the listener use limiter as its global read limiter instead of creating one, so several
+// listeners can share one global budget, for example an IPv4 and an IPv6 listener of the same service. The limit
+// set through WithLimits then only applies to the per-connection limit, and SetLimits on any of the listeners
+// changes the shared limiter.
+func WithGlobalLimiter(limiter *rate.Limiter) Option {
+	return func(o *options) {
+		o.globalLimiter = limiter
+	}
+}
+
+// CloneConfigOnto wraps inner in a new listener configured like l: the same options, the limits, global burst
+// and write limit currently enforced by l, its tenant limits and its measure-only mode. The clone gets limiters
+// of its own, so both listeners have separate budgets; pass WithGlobalLimiter(l.GlobalLimiter()) to share the
+// global one. opts are applied on top of the cloned options.
+func (l *LimitedListener) CloneConfigOnto(inner net.Listener, opts ...Option) (*LimitedListener, error) {
+	config := l.ExportConfig()
+
+	o := l.opts
+	o.globalLimit = config.GlobalLimit
+	o.perConnLimit = config.PerConnLimit
+	o.globalWriteLimit = config.GlobalWriteLimit
+	// A global limiter l was given with WithGlobalLimiter is only shared if opts pass it again.
+	o.globalLimiter = nil
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	clone, err := newLimitedListener(inner, o)
+	if err != nil {
+		return nil, err
+	}
+	// A burst tuned through GlobalLimiter is carried over, unless opts changed the global limit it was tuned for.
+	if clone.globalReadLimiter != l.globalReadLimiter && o.globalLimit == config.GlobalLimit {
+		clone.globalReadLimiter.SetBurst(config.GlobalBurst)
+	}
+	clone.measureOnly.Store(l.measureOnly.Load())
+
+	l.tenantMu.Lock()
+	defer l.tenantMu.Unlock()
+	for tenant, limit := range l.tenantLimits {
+		clone.SetTenantLimit(tenant, limit)
+	}
+	return clone, nil
+}
@@ -0,0 +1,121 @@
+package limitedlistener
+
+import (
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// TestCloneConfigOnto verifies that a cloned listener enforces the same live limits as the original one.
+func TestCloneConfigOnto(t *testing.T) {
+	original, err := Listen("tcp", "127.0.0.1:0", WithLimits(100_000, 50_000), WithMaxConnections(10))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer original.Close()
+	original.SetLimits(10_000, 1_000)
+	original.SetTenantLimit("tenant", 500)
+
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen error: %v", err)
+	}
+	clone, err := original.CloneConfigOnto(inner)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer clone.Close()
+
+	if got, want := clone.ExportConfig(), original.ExportConfig(); got != want {
+		t.Errorf("expected the clone to have the config %+v, got %+v", want, got)
+	}
+	if clone.GlobalLimiter() == original.GlobalLimiter() {
+		t.Errorf("expected the clone to have a global limiter of its own")
+	}
+	if clone.opts.maxConnections != 10 || clone.tenantLimits["tenant"] != 500 {
+		t.Errorf("expected the clone to keep the options and tenant limits")
+	}
+
+	var wg sync.WaitGroup
+	for _, listener := range []*LimitedListener{original, clone} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			client, err := net.Dial("tcp", listener.Addr().String())
+			if err != nil {
+				t.Errorf("dial error: %v", err)
+				return
+			}
+			defer client.Close()
+			conn, err := listener.Accept()
+			if err != nil {
+				t.Errorf("accept error: %v", err)
+				return
+			}
+			defer conn.Close()
+
+			go client.Write(make([]byte, 1_500))
+			start := time.Now()
+			if _, err := io.ReadFull(conn, make([]byte, 1_500)); err != nil {
+				t.Errorf("read error: %v", err)
+				return
+			}
+			if elapsed := time.Since(start); elapsed < 450*time.Millisecond || elapsed > time.Second {
+				t.Errorf("expected both listeners to enforce 1000 bytes per second per connection, but it took %v", elapsed)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestCloneConfigOntoSharedGlobalLimiter verifies that the clone can share the global limiter of the original.
+func TestCloneConfigOntoSharedGlobalLimiter(t *testing.T) {
+	original, err := NewLimitedListener(nil, 10_000, 1_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	clone, err := original.CloneConfigOnto(nil, WithGlobalLimiter(original.GlobalLimiter()))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	if clone.GlobalLimiter() != original.GlobalLimiter() {
+		t.Errorf("expected the clone to share the global limiter of the original")
+	}
+
+	clone.SetLimits(20_000, 2_000)
+	if got := original.ExportConfig().GlobalLimit; got != 20_000 {
+		t.Errorf("expected a change through the clone to apply to the shared global limiter, got %d", got)
+	}
+}
+
+// TestCloneConfigOntoOwnGlobalLimiter verifies that a clone of a listener created with WithGlobalLimiter gets a
+// global limiter of its own unless it is passed again.
+func TestCloneConfigOntoOwnGlobalLimiter(t *testing.T) {
+	shared := rate.NewLimiter(10_000, 10_000)
+	original, err := NewLimitedListener(nil, 10_000, 1_000, WithGlobalLimiter(shared))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	clone, err := original.CloneConfigOnto(nil)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	if clone.GlobalLimiter() == shared {
+		t.Fatalf("expected the clone to get a global limiter of its own")
+	}
+	if got := clone.ExportConfig().GlobalLimit; got != 10_000 {
+		t.Errorf("expected the clone to keep the global limit of 10000, got %d", got)
+	}
+
+	clone.SetLimits(20_000, 2_000)
+	if got := original.ExportConfig().GlobalLimit; got != 10_000 {
+		t.Errorf("expected a change through the clone to leave the original alone, got %d", got)
+	}
+}
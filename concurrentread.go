@@ -0,0 +1,32 @@
+package limitedlistener
+
+import "fmt"
+
+var ErrConcurrentRead = fmt.Errorf("concurrent read on the same connection")
+
+// WithConcurrentReadGuard guards against calling Read concurrently on the same connection, which interleaves
+// the limiter waits unpredictably and is not supported by most net.Conn implementations. With serialize set,
+// concurrent reads wait for each other; otherwise a read started while another one is in progress returns
+// (0, ErrConcurrentRead) right away. Without the option concurrent reads are passed through as they are.
+func WithConcurrentReadGuard(serialize bool) Option {
+	return func(o *options) {
+		o.readGuard = true
+		o.serializeReads = serialize
+	}
+}
+
+// enterRead applies the concurrent read guard, returning the function ending the read.
+func (lc *LimitedConnection) enterRead() (func(), error) {
+	o := lc.options()
+	switch {
+	case !o.readGuard:
+		return func() {}, nil
+	case o.serializeReads:
+		lc.readMu.Lock()
+		return lc.readMu.Unlock, nil
+	case !lc.reading.CompareAndSwap(false, true):
+		return nil, ErrConcurrentRead
+	default:
+		return func() { lc.reading.Store(false) }, nil
+	}
+}
@@ -0,0 +1,73 @@
+package limitedlistener
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestConcurrentReadGuard verifies that a second concurrent Read is rejected with ErrConcurrentRead.
+func TestConcurrentReadGuard(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 1_000, 1_000, WithConcurrentReadGuard(false))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	blocked := make(chan error, 1)
+	go func() {
+		_, err := lc.Read(make([]byte, 10))
+		blocked <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	if _, err := lc.Read(make([]byte, 10)); !errors.Is(err, ErrConcurrentRead) {
+		t.Errorf("expected ErrConcurrentRead, got %v", err)
+	}
+
+	client.Write(make([]byte, 10))
+	if err := <-blocked; err != nil {
+		t.Errorf("expected the first read to complete, got %v", err)
+	}
+
+	go client.Write(make([]byte, 10))
+	if _, err := lc.Read(make([]byte, 10)); err != nil {
+		t.Errorf("expected reads to be accepted again once the first one returned, got %v", err)
+	}
+}
+
+// TestConcurrentReadSerialized verifies that concurrent reads are serialized and all complete.
+func TestConcurrentReadSerialized(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 1_000_000, 1_000_000, WithConcurrentReadGuard(true))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	const readers = 8
+	go client.Write(make([]byte, readers*10))
+
+	var wg sync.WaitGroup
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if n, err := lc.Read(make([]byte, 10)); n != 10 || err != nil {
+				t.Errorf("expected a serialized read of 10 bytes, got (%d, %v)", n, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,48 @@
+package limitedlistener
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+)
+
+// Config describes the live bandwidth configuration of a LimitedListener. Limits are in bytes per second,
+// where zero means unlimited.
+type Config struct {
+	GlobalLimit      int
+	GlobalBurst      int
+	PerConnLimit     int
+	GlobalWriteLimit int
+}
+
+// ExportConfig returns the limits the listener currently enforces, read from the live limiters so that
+// changes made through GlobalLimiter are reflected as well.
+func (l *LimitedListener) ExportConfig() Config {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return Config{
+		GlobalLimit:      limitOf(l.globalReadLimiter),
+		GlobalBurst:      l.globalReadLimiter.Burst(),
+		PerConnLimit:     l.perConnBandwidthLimit,
+		GlobalWriteLimit: limitOf(l.globalWriteLimiter),
+	}
+}
+
+// ConfigHash returns a stable hash of the live configuration. Comparing it against the hash of the intended
+// configuration lets a controller detect drift cheaply.
+func (l *LimitedListener) ConfigHash() uint64 {
+	return l.ExportConfig().Hash()
+}
+
+// Hash returns a stable FNV-1a hash of the configuration.
+func (c Config) Hash() uint64 {
+	var buf [32]byte
+	binary.BigEndian.PutUint64(buf[0:], uint64(c.GlobalLimit))
+	binary.BigEndian.PutUint64(buf[8:], uint64(c.GlobalBurst))
+	binary.BigEndian.PutUint64(buf[16:], uint64(c.PerConnLimit))
+	binary.BigEndian.PutUint64(buf[24:], uint64(c.GlobalWriteLimit))
+
+	h := fnv.New64a()
+	h.Write(buf[:])
+	return h.Sum64()
+}
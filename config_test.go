@@ -0,0 +1,39 @@
+package limitedlistener
+
+import "testing"
+
+// TestConfigHash verifies that the exported config reflects the live limits and that the hash changes with them.
+func TestConfigHash(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 100, 50)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	want := Config{GlobalLimit: 100, GlobalBurst: 100, PerConnLimit: 50}
+	if got := listener.ExportConfig(); got != want {
+		t.Errorf("expected %+v, but got %+v", want, got)
+	}
+
+	before := listener.ConfigHash()
+	if before != want.Hash() {
+		t.Errorf("expected the hash of the intended config")
+	}
+	if listener.ConfigHash() != before {
+		t.Errorf("expected the hash to be stable")
+	}
+
+	listener.SetLimits(50, 50)
+	if listener.ConfigHash() == before {
+		t.Errorf("expected the hash to change after SetLimits")
+	}
+
+	listener.SetLimits(100, 50)
+	if listener.ConfigHash() != before {
+		t.Errorf("expected the hash to match again after restoring the limits")
+	}
+
+	listener.GlobalLimiter().SetBurst(10)
+	if listener.ConfigHash() == before {
+		t.Errorf("expected the hash to change after tuning the global limiter directly")
+	}
+}
@@ -0,0 +1,67 @@
+package limitedlistener
+
+import (
+	"time"
+)
+
+// maxControllerStep bounds the relative change the throughput controller makes to the global limit per window.
+const maxControllerStep = 0.2
+
+// WithThroughputController adjusts the global limit so that the achieved aggregate read throughput converges to
+// target bytes per second; writes are not counted, as the global limit only throttles reads. Every window the achieved rate is compared to the target and the
+// global limit is nudged by at most 20% towards it, compensating for overhead such as WithReadOverhead that keeps
+// the achieved rate below the configured one. The limit is never raised above four times the target, nor while
+// the global limiter still has more than half of its burst available, which means demand rather than the limit
+// bounds the throughput. It is never lowered below the per-connection limit. Limits set with SetLimits are adjusted from the next window on.
+func WithThroughputController(target int, window time.Duration) Option {
+	return func(o *options) {
+		o.controllerTarget = target
+		o.controllerWindow = window
+	}
+}
+
+// runThroughputController samples the bytes read every window and adjusts the global limit until the
+// listener is closed.
+func (l *LimitedListener) runThroughputController() {
+	clock := l.opts.clock
+	target := float64(l.opts.controllerTarget)
+
+	last, lastBytes := clock.Now(), l.bytesRead.Load()
+	for {
+		select {
+		case <-clock.After(l.opts.controllerWindow):
+		case <-l.done:
+			return
+		}
+
+		now, bytes := clock.Now(), l.bytesRead.Load()
+		elapsed := now.Sub(last).Seconds()
+		achieved := float64(bytes-lastBytes) / elapsed
+		last, lastBytes = now, bytes
+		if elapsed <= 0 || achieved <= 0 {
+			continue
+		}
+
+		l.adjustGlobalLimit(min(max(target/achieved, 1-maxControllerStep), 1+maxControllerStep), 4*target)
+	}
+}
+
+// adjustGlobalLimit multiplies the global limit by factor, capped at ceiling and floored at the per-connection
+// limit. The limit is not raised while the global limiter isn't the bottleneck.
+func (l *LimitedListener) adjustGlobalLimit(factor, ceiling float64) {
+	l.setLimitsMu.Lock()
+	defer l.setLimitsMu.Unlock()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	current := limitOf(l.globalReadLimiter)
+	if current == 0 {
+		return
+	}
+	if factor > 1 && l.globalReadLimiter.Tokens() > float64(l.globalReadLimiter.Burst())/2 {
+		return
+	}
+	next := int(min(float64(current)*factor, ceiling))
+	setLimiterRate(l.globalReadLimiter, max(next, l.perConnBandwidthLimit, 1))
+}
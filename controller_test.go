@@ -0,0 +1,98 @@
+package limitedlistener
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// TestThroughputController verifies that the controller raises the global limit until the achieved throughput
+// reaches the target, although the read overhead halves the payload rate of the configured limit.
+func TestThroughputController(t *testing.T) {
+	const target = 10_000
+
+	listener, err := NewLimitedListener(nil, target, target,
+		WithReadOverhead(100), WithThroughputController(target, 100*time.Millisecond))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer listener.Close()
+
+	for i := 0; i < 4; i++ {
+		server, client := net.Pipe()
+		defer client.Close()
+
+		lc := admitConn(t, listener, server)
+		defer lc.Close()
+
+		go func() {
+			chunk := make([]byte, 1_000)
+			for {
+				if _, err := client.Write(chunk); err != nil {
+					return
+				}
+			}
+		}()
+		go func() {
+			buf := make([]byte, 100)
+			for {
+				if _, err := lc.Read(buf); err != nil {
+					return
+				}
+			}
+		}()
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+
+	start, startBytes := time.Now(), listener.TotalBytes()
+	time.Sleep(time.Second)
+	achieved := float64(listener.TotalBytes()-startBytes) / time.Since(start).Seconds()
+
+	if achieved < target*0.85 || achieved > target*1.15 {
+		t.Errorf("expected the achieved throughput to converge to %d B/s, got %.0f B/s (global limit %d)",
+			target, achieved, listener.ExportConfig().GlobalLimit)
+	}
+}
+
+// TestThroughputControllerBounds verifies that the controller ignores writes and never lowers the global limit
+// below the per-connection limit.
+func TestThroughputControllerBounds(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 10_000, 8_000, WithThroughputController(1_000, 50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer listener.Close()
+
+	server, client := net.Pipe()
+	defer client.Close()
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	go func() {
+		buf := make([]byte, 1_000)
+		for {
+			if _, err := client.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+	go func() {
+		chunk := make([]byte, 1_000)
+		for {
+			if _, err := lc.Write(chunk); err != nil {
+				return
+			}
+		}
+	}()
+
+	time.Sleep(300 * time.Millisecond)
+	if got := listener.ExportConfig().GlobalLimit; got != 10_000 {
+		t.Errorf("expected writes to leave the global limit at 10000, got %d", got)
+	}
+
+	listener.adjustGlobalLimit(0.5, 4_000)
+	if got := listener.ExportConfig().GlobalLimit; got != 8_000 {
+		t.Errorf("expected the global limit to stop at the per-connection limit of 8000, got %d", got)
+	}
+}
@@ -0,0 +1,62 @@
+package limitedlistener
+
+import "time"
+
+// rateDecayInterval is how often the rate decay function is applied to the connections.
+const rateDecayInterval = 100 * time.Millisecond
+
+// WithRateDecay lets the per-connection limit follow the life of each connection, for example generous at first and
+// tapering off as the connection transfers more, so short transfers stay fast while long bulk ones are slowed down.
+// fn is given the number of bytes read so far and the age of the connection and returns the per-connection limit in
+// bytes per second, where zero means unlimited and a negative value keeps the current limit.
+//
+// fn is applied when a connection is accepted and then every 100ms, on a background goroutine, to every tracked
+// connection not pinned with PinLimit. It overrides the per-connection limit set with SetLimits from the next tick.
+func WithRateDecay(fn func(bytesSoFar int64, age time.Duration) int) Option {
+	return func(o *options) {
+		o.rateDecay = fn
+	}
+}
+
+// initialDecayedRate returns the per-connection limit the rate decay function gives a new connection, or -1 without
+// one. It calls user code, so it must be called without holding the listener lock.
+func (l *LimitedListener) initialDecayedRate() int {
+	if l.opts.rateDecay == nil {
+		return -1
+	}
+	return l.opts.rateDecay(0, 0)
+}
+
+// runRateDecay applies the rate decay function to the tracked connections every rateDecayInterval until the
+// listener is closed.
+func (l *LimitedListener) runRateDecay() {
+	ticker := time.NewTicker(rateDecayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+			for _, connection := range l.trackedConnections() {
+				if !connection.pinned.Load() && connection.applyRateDecay(l.opts.rateDecay) {
+					connection.notifyLimiterChange()
+				}
+			}
+		}
+	}
+}
+
+// applyRateDecay sets the per-connection limit to the one returned by decay for the current state of the connection.
+// It reports whether the limit changed.
+func (lc *LimitedConnection) applyRateDecay(decay func(int64, time.Duration) int) bool {
+	bytesPerSecond := decay(lc.bytesRead.Load(), time.Since(lc.createdAt))
+	if bytesPerSecond < 0 {
+		return false
+	}
+
+	lc.mu.Lock()
+	current := lc.bytesPerSecond
+	lc.mu.Unlock()
+	return bytesPerSecond != current && setConnRate(lc, bytesPerSecond)
+}
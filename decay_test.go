@@ -0,0 +1,99 @@
+package limitedlistener
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestRateDecay verifies that the throughput of a long transfer tapers off once the decay function lowers the
+// per-connection limit.
+func TestRateDecay(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 1_000_000, 1_000_000, WithRateDecay(func(bytesSoFar int64, age time.Duration) int {
+		if bytesSoFar < 50_000 {
+			return 50_000
+		}
+		return 5_000
+	}))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer listener.Close()
+
+	server, client := net.Pipe()
+	defer client.Close()
+	lc, err := listener.admit(server)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer lc.Close()
+	if got := lc.Snapshot().PerConnLimit; got != 50_000 {
+		t.Errorf("expected the decay function to set the initial limit to 50000, got %d", got)
+	}
+
+	go func() {
+		chunk := make([]byte, 1_000)
+		for {
+			if _, err := client.Write(chunk); err != nil {
+				return
+			}
+		}
+	}()
+
+	var received atomic.Int64
+	go func() {
+		buf := make([]byte, 1_000)
+		for {
+			n, err := lc.Read(buf)
+			received.Add(int64(n))
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	time.Sleep(300 * time.Millisecond)
+	if got := received.Load(); got < 50_000 {
+		t.Fatalf("expected the start of the transfer to be fast, got %d bytes in 300ms", got)
+	}
+
+	before := received.Load()
+	time.Sleep(500 * time.Millisecond)
+	if got := received.Load() - before; got > 5_000 {
+		t.Errorf("expected the transfer to taper to 5000 bytes per second, got %d bytes in 500ms", got)
+	}
+	if got := lc.Snapshot().PerConnLimit; got != 5_000 {
+		t.Errorf("expected the decayed limit to be 5000, got %d", got)
+	}
+}
+
+// TestRateDecayWithoutListenerLock verifies that the decay function may call back into the listener when a
+// connection is accepted.
+func TestRateDecayWithoutListenerLock(t *testing.T) {
+	var listener *LimitedListener
+	listener, err := NewLimitedListener(pipeListener{}, 1_000_000, 1_000_000, WithRateDecay(func(bytesSoFar int64, age time.Duration) int {
+		listener.Stats()
+		return 10_000
+	}))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer listener.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, _ := listener.Accept()
+		accepted <- conn
+	}()
+
+	select {
+	case conn := <-accepted:
+		if got := conn.(*LimitedConnection).Snapshot().PerConnLimit; got != 10_000 {
+			t.Errorf("expected the decay function to set the initial limit to 10000, got %d", got)
+		}
+		conn.Close()
+	case <-time.After(time.Second):
+		t.Fatal("expected Accept to return, but it deadlocked calling the decay function")
+	}
+}
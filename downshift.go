@@ -0,0 +1,29 @@
+package limitedlistener
+
+import (
+	"math"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// WithStrictDownshift makes lowering the global limit also lower the tokens left in the global limiter in the
+// same proportion, so the tokens saved up under the old limit can't be spent as a burst right after the change.
+// Without it the tokens are only capped at the new burst. Raising the limit leaves the tokens alone.
+func WithStrictDownshift() Option {
+	return func(o *options) {
+		o.strictDownshift = true
+	}
+}
+
+// scaleDownTokens lowers the tokens available in limiter at t to the share of tokens, the tokens it had before its
+// limit was lowered from old, kept by the new limit.
+func scaleDownTokens(limiter *rate.Limiter, old rate.Limit, tokens float64, t time.Time) {
+	limit := limiter.Limit()
+	if old == rate.Inf || limit >= old || tokens <= 0 {
+		return
+	}
+	if excess := int(math.Ceil(limiter.TokensAt(t) - tokens*float64(limit/old))); excess > 0 {
+		limiter.AllowN(t, excess)
+	}
+}
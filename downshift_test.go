@@ -0,0 +1,31 @@
+package limitedlistener
+
+import (
+	"testing"
+	"time"
+)
+
+// TestStrictDownshift verifies that lowering the global limit scales the saved tokens down with it, so no burst
+// above the new rate is left, and that raising the limit keeps them.
+func TestStrictDownshift(t *testing.T) {
+	tokensAfter := func(global int, opts ...Option) float64 {
+		listener, err := NewLimitedListener(nil, 10_000, 1_000, opts...)
+		if err != nil {
+			t.Fatalf("didn't expect error but got one: %v", err)
+		}
+		now := time.Now()
+		listener.globalReadLimiter.ReserveN(now, 5_000)
+		listener.SetLimitsAt(now, global, 1_000)
+		return listener.globalReadLimiter.TokensAt(now)
+	}
+
+	if tokens := tokensAfter(4_000); tokens != 4_000 {
+		t.Errorf("expected the tokens to be capped at the new burst of 4000 by default, got %.0f", tokens)
+	}
+	if tokens := tokensAfter(4_000, WithStrictDownshift()); tokens != 2_000 {
+		t.Errorf("expected the 5000 tokens to be scaled down to 2000 with the limit, got %.0f", tokens)
+	}
+	if tokens := tokensAfter(20_000, WithStrictDownshift()); tokens != 5_000 {
+		t.Errorf("expected raising the limit to keep the 5000 tokens, got %.0f", tokens)
+	}
+}
@@ -0,0 +1,59 @@
+package limitedlistener
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// DrainRead reads and discards the inbound data of the connection until EOF or until d has passed, throttled by
+// the limiters like any other read, and returns the number of bytes drained. Draining the data the peer is still
+// sending before Close lets the connection close cleanly, where closing with unread data makes the kernel send a
+// reset. It returns nil at EOF and os.ErrDeadlineExceeded if d passed first, or as soon as the limiters can't
+// grant another byte within d. The read deadline set before the call is restored afterwards.
+func (lc *LimitedConnection) DrainRead(d time.Duration) (int64, error) {
+	deadline := time.Now().Add(d)
+	ctx, cancel := context.WithDeadline(lc.context(), deadline)
+	defer cancel()
+
+	previous := lc.readDeadline.Load()
+	if err := lc.SetReadDeadline(deadline); err != nil {
+		return 0, err
+	}
+	defer func() {
+		if previous == 0 {
+			lc.SetReadDeadline(time.Time{})
+			return
+		}
+		lc.SetReadDeadline(time.Unix(0, previous))
+	}()
+
+	pooled, buf := getCopyBuffer(0)
+	defer putCopyBuffer(pooled)
+
+	var drained int64
+	for {
+		// Reads are sized to what the limiters can grant before the deadline, so no wait overruns it.
+		var limiters [4]*rate.Limiter
+		size := affordableWithin(time.Until(deadline), len(buf), lc.limiters(limiters[:0])...)
+		if size == 0 {
+			return drained, os.ErrDeadlineExceeded
+		}
+
+		n, err := lc.read(ctx, buf[:size])
+		drained += int64(n)
+		switch {
+		case err == nil:
+		case errors.Is(err, io.EOF):
+			return drained, nil
+		case errors.Is(err, os.ErrDeadlineExceeded) || ctx.Err() != nil:
+			return drained, os.ErrDeadlineExceeded
+		default:
+			return drained, err
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package limitedlistener
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestDrainRead verifies that the pending inbound data is drained until EOF before the connection is closed.
+func TestDrainRead(t *testing.T) {
+	server, client := net.Pipe()
+
+	listener, err := NewLimitedListener(nil, 100_000, 10_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	go func() {
+		client.Write(make([]byte, 15_000))
+		client.Close()
+	}()
+
+	start := time.Now()
+	drained, err := lc.DrainRead(time.Second)
+	if err != nil {
+		t.Fatalf("expected the drain to end at EOF, got %v", err)
+	}
+	if drained != 15_000 {
+		t.Errorf("expected 15000 bytes to be drained, got %d", drained)
+	}
+	if elapsed := time.Since(start); elapsed < 450*time.Millisecond {
+		t.Errorf("expected the drain to be throttled, but it took %v", elapsed)
+	}
+	if got := lc.readDeadline.Load(); got != 0 {
+		t.Errorf("expected the read deadline to be cleared after the drain, got %v", time.Unix(0, got))
+	}
+}
+
+// TestDrainReadTimeout verifies that the drain stops once its duration has passed.
+func TestDrainReadTimeout(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 100_000, 1_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	go client.Write(make([]byte, 10_000))
+
+	start := time.Now()
+	drained, err := lc.DrainRead(200 * time.Millisecond)
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("expected os.ErrDeadlineExceeded, got %v", err)
+	}
+	if drained < 1_000 || drained > 1_500 {
+		t.Errorf("expected about the burst plus 200ms of data to be drained, got %d bytes", drained)
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("expected the drain to stop after 200ms, but it took %v", elapsed)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -28,18 +29,13 @@ func NewServer() *Server {
 }
 
 func (s *Server) Start(global, perConn int) error {
-	listener, err := net.Listen("tcp", ":8080")
+	ln, err := limitedlistener.Listen("tcp", ":8080", limitedlistener.WithLimits(global, perConn))
 	if err != nil {
 		return err
 	}
-	defer listener.Close()
+	defer ln.Close()
 
-	limitedlistener, err := limitedlistener.NewLimitedListener(listener, global, perConn)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	s.ln = limitedlistener
+	s.ln = ln
 	fmt.Println("Listening on port 8080")
 
 	go s.acceptLoop()
@@ -52,6 +48,9 @@ func (s *Server) Start(global, perConn int) error {
 func (s *Server) acceptLoop() {
 	for {
 		conn, err := s.ln.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
 		if err != nil {
 			fmt.Println("accept error:", err)
 			continue
@@ -181,15 +180,15 @@ func changingLimitsOnRuntime() {
 			fmt.Printf("\n%s -> Conn %s read %d bytes", time.Now().Format(time.DateTime), connInfo.Addr, connInfo.bytesRead)
 
 		case <-tickerIncrease.C:
-			globalRate = globalRate * 2
-			perConnRate = perConnRate * 2
-			server.ln.SetLimits(globalRate, perConnRate)
+			if err := server.ln.ScaleLimits(2); err != nil {
+				log.Printf("scaling limits: %v", err)
+			}
 			fmt.Printf("\n%s -> Doubling the Limit", time.Now().Format(time.DateTime))
 
 		case <-tickerReduce.C:
-			globalRate = globalRate / 2
-			perConnRate = perConnRate / 2
-			server.ln.SetLimits(globalRate, perConnRate)
+			if err := server.ln.ScaleLimits(0.5); err != nil {
+				log.Printf("scaling limits: %v", err)
+			}
 			fmt.Printf("\n%s -> Halving the Limit", time.Now().Format(time.DateTime))
 
 		case <-transferFinished:
@@ -0,0 +1,45 @@
+package limitedlistener
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// expvarMu guards expvarTargets.
+	expvarMu sync.Mutex
+	// expvarTargets maps every published prefix to the listener its variables currently report on.
+	expvarTargets = make(map[string]*atomic.Pointer[LimitedListener])
+)
+
+// PublishExpvar publishes the listener's key metrics through expvar, so they show up on /debug/vars:
+// prefix.active_connections, prefix.total_bytes, prefix.global_limit, prefix.per_conn_limit and
+// prefix.global_write_limit. Limits of zero mean unlimited.
+//
+// expvar variables cannot be unregistered, so publishing again with the same prefix, for example from a
+// replacement listener, rebinds the existing variables to l instead of panicking on the duplicate names.
+func (l *LimitedListener) PublishExpvar(prefix string) {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+
+	if target, ok := expvarTargets[prefix]; ok {
+		target.Store(l)
+		return
+	}
+
+	target := new(atomic.Pointer[LimitedListener])
+	target.Store(l)
+	expvarTargets[prefix] = target
+
+	publish := func(name string, value func(Stats) any) {
+		expvar.Publish(prefix+"."+name, expvar.Func(func() any {
+			return value(target.Load().Stats())
+		}))
+	}
+	publish("active_connections", func(s Stats) any { return s.ActiveConnections })
+	publish("total_bytes", func(s Stats) any { return s.TotalBytes })
+	publish("global_limit", func(s Stats) any { return s.GlobalLimit })
+	publish("per_conn_limit", func(s Stats) any { return s.PerConnLimit })
+	publish("global_write_limit", func(s Stats) any { return s.GlobalWriteLimit })
+}
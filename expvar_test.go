@@ -0,0 +1,44 @@
+package limitedlistener
+
+import (
+	"expvar"
+	"testing"
+)
+
+// TestPublishExpvar verifies that the published variables report the listener's metrics and that publishing
+// again with the same prefix rebinds them instead of panicking.
+func TestPublishExpvar(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 1_000, 100)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	listener.PublishExpvar("test_publish")
+	listener.recordBytes(42)
+
+	for name, want := range map[string]string{
+		"test_publish.active_connections": "0",
+		"test_publish.total_bytes":        "42",
+		"test_publish.global_limit":       "1000",
+		"test_publish.per_conn_limit":     "100",
+		"test_publish.global_write_limit": "0",
+	} {
+		v := expvar.Get(name)
+		if v == nil {
+			t.Errorf("expected %s to be published", name)
+			continue
+		}
+		if got := v.String(); got != want {
+			t.Errorf("expected %s to be %s, got %s", name, want, got)
+		}
+	}
+
+	replacement, err := NewLimitedListener(nil, 2_000, 200)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	replacement.PublishExpvar("test_publish")
+
+	if got := expvar.Get("test_publish.global_limit").String(); got != "2000" {
+		t.Errorf("expected the variables to report on the replacement listener, got a global limit of %s", got)
+	}
+}
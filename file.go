@@ -0,0 +1,21 @@
+package limitedlistener
+
+import (
+	"fmt"
+	"os"
+)
+
+var ErrNoFile = fmt.Errorf("underlying listener doesn't expose a file")
+
+// File returns a copy of the underlying listener's file, such as the one of a *net.TCPListener, so its
+// descriptor can be handed to a new process for a graceful restart. Closing the returned file doesn't
+// affect the listener, and closing the listener doesn't affect the file.
+//
+// It returns ErrNoFile if the underlying listener has no File method.
+func (l *LimitedListener) File() (*os.File, error) {
+	filer, ok := l.Listener.(interface{ File() (*os.File, error) })
+	if !ok {
+		return nil, ErrNoFile
+	}
+	return filer.File()
+}
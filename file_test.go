@@ -0,0 +1,43 @@
+package limitedlistener
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+// TestFile verifies that File returns a usable descriptor for a TCP listener and ErrNoFile otherwise.
+func TestFile(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	listener, err := NewLimitedListener(ln, 1_000, 100)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer listener.Close()
+
+	f, err := listener.File()
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer f.Close()
+
+	inherited, err := net.FileListener(f)
+	if err != nil {
+		t.Fatalf("expected the file to hold a listener, got error: %v", err)
+	}
+	defer inherited.Close()
+	if inherited.Addr().String() != listener.Addr().String() {
+		t.Errorf("expected the inherited listener on %v, got %v", listener.Addr(), inherited.Addr())
+	}
+
+	other, err := NewLimitedListener(pipeListener{}, 1_000, 100)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	if _, err := other.File(); !errors.Is(err, ErrNoFile) {
+		t.Errorf("expected ErrNoFile, got %v", err)
+	}
+}
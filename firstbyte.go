@@ -0,0 +1,45 @@
+package limitedlistener
+
+import (
+	"fmt"
+	"time"
+)
+
+var ErrNoFirstByte = fmt.Errorf("connection sent no data within the first byte timeout")
+
+// WithFirstByteTimeout closes accepted connections whose first byte hasn't been read within d of being accepted,
+// such as slow-loris clients that connect and stay silent. Reads on such a connection return ErrNoFirstByte.
+// Unlike a deadline it only applies until the first byte, and it doesn't depend on the handler calling Read.
+func WithFirstByteTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.firstByteTimeout = d
+	}
+}
+
+// armFirstByteTimer closes the connection with ErrNoFirstByte if nothing has been read from it within d.
+func (lc *LimitedConnection) armFirstByteTimer(d time.Duration) {
+	lc.firstByteTimer.Store(time.AfterFunc(d, func() {
+		if lc.bytesRead.Load() == 0 {
+			lc.noFirstByte.Store(true)
+			lc.Close()
+		}
+	}))
+}
+
+// disarmFirstByteTimer stops the first byte timer, if it is still running.
+func (lc *LimitedConnection) disarmFirstByteTimer() {
+	if lc.firstByteTimer.Load() == nil {
+		return
+	}
+	if timer := lc.firstByteTimer.Swap(nil); timer != nil {
+		timer.Stop()
+	}
+}
+
+// firstByteError replaces err with ErrNoFirstByte if the connection was closed by the first byte timer.
+func (lc *LimitedConnection) firstByteError(err error) error {
+	if err != nil && lc.noFirstByte.Load() {
+		return ErrNoFirstByte
+	}
+	return err
+}
@@ -0,0 +1,64 @@
+package limitedlistener
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestFirstByteTimeout verifies that a silent client is closed once the first byte timeout passes, while one that
+// sends data in time is left alone.
+func TestFirstByteTimeout(t *testing.T) {
+	listener, err := Listen("tcp", "127.0.0.1:0", WithFirstByteTimeout(200*time.Millisecond))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer listener.Close()
+
+	accept := func() (net.Conn, net.Conn) {
+		client, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("didn't expect error but got one: %v", err)
+		}
+		conn, err := listener.Accept()
+		if err != nil {
+			t.Fatalf("didn't expect error but got one: %v", err)
+		}
+		return client, conn
+	}
+
+	silent, conn := accept()
+	defer silent.Close()
+	start := time.Now()
+	if _, err := conn.Read(make([]byte, 10)); !errors.Is(err, ErrNoFirstByte) {
+		t.Errorf("expected ErrNoFirstByte, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond || elapsed > time.Second {
+		t.Errorf("expected the silent connection to be closed after about 200ms, took %v", elapsed)
+	}
+	silent.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := silent.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("expected the client to see the connection closed, got %v", err)
+	}
+	// The connection is removed from the listener right after the underlying connection is closed.
+	for deadline := time.Now().Add(time.Second); listener.Stats().ActiveConnections != 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the silent connection to be removed from the listener")
+		}
+	}
+
+	talkative, conn := accept()
+	defer talkative.Close()
+	defer conn.Close()
+	talkative.Write([]byte("x"))
+	if _, err := conn.Read(make([]byte, 10)); err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	talkative.Write([]byte("y"))
+	if _, err := conn.Read(make([]byte, 10)); err != nil {
+		t.Errorf("expected the connection to survive past the timeout once it sent data, got %v", err)
+	}
+}
@@ -2,4 +2,7 @@ module github.com/aubermardegan/limitedlistener
 
 go 1.22.0
 
-require golang.org/x/time v0.10.0
+require (
+	golang.org/x/sync v0.8.0
+	golang.org/x/time v0.10.0
+)
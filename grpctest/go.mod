@@ -0,0 +1,20 @@
+module github.com/aubermardegan/limitedlistener/grpctest
+
+go 1.22.0
+
+require (
+	github.com/aubermardegan/limitedlistener v0.0.0
+	google.golang.org/grpc v1.67.1
+)
+
+require (
+	golang.org/x/net v0.28.0 // indirect
+	golang.org/x/sync v0.8.0 // indirect
+	golang.org/x/sys v0.24.0 // indirect
+	golang.org/x/text v0.17.0 // indirect
+	golang.org/x/time v0.10.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240814211410-ddb44dafa142 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+)
+
+replace github.com/aubermardegan/limitedlistener => ../
@@ -0,0 +1,88 @@
+package grpctest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aubermardegan/limitedlistener"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// rawCodec passes []byte messages through as is, so the test doesn't need generated protobuf code.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	return *(v.(*[]byte)), nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	*(v.(*[]byte)) = append([]byte(nil), data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "raw"
+}
+
+// TestGRPCUnaryCall verifies that a gRPC server behind a LimitedListener still completes a unary call with a large
+// request and response, only slower. HTTP/2 frames are read in burst-sized chunks, which the framer handles like any
+// short read, and the limiter waits don't interfere with the deadlines gRPC sets on the connection.
+func TestGRPCUnaryCall(t *testing.T) {
+	const limit = 100_000
+	const payloadSize = 150_000
+
+	listener, err := limitedlistener.Listen("tcp", "127.0.0.1:0", limitedlistener.WithLimits(limit, limit), limitedlistener.WithGlobalWriteLimit(limit))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	server := grpc.NewServer(grpc.ForceServerCodec(rawCodec{}))
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "limitedlistener.Test",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Download",
+			Handler: func(_ any, _ context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				var request []byte
+				if err := dec(&request); err != nil {
+					return nil, err
+				}
+				response := make([]byte, payloadSize)
+				return &response, nil
+			},
+		}},
+	}, struct{}{})
+	go server.Serve(listener)
+	defer server.Stop()
+
+	client, err := grpc.NewClient(listener.Addr().String(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(rawCodec{})),
+	)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	request := make([]byte, payloadSize)
+	var response []byte
+
+	start := time.Now()
+	if err := client.Invoke(ctx, "/limitedlistener.Test/Download", &request, &response); err != nil {
+		t.Fatalf("unary call failed: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	if len(response) != payloadSize {
+		t.Errorf("expected a response of %d bytes, but got %d", payloadSize, len(response))
+	}
+	// Both directions exceed the initial burst by 50 KB, each taking at least half a second at the limit.
+	if elapsed < 800*time.Millisecond {
+		t.Errorf("expected the call to be throttled, but it took %v", elapsed)
+	}
+}
@@ -0,0 +1,66 @@
+package limitedlistener
+
+import (
+	"bufio"
+	"io"
+	"net"
+)
+
+// HijackBuffered returns a net.Conn for a connection taken over with http.Hijacker, such as a WebSocket upgrade.
+// The *bufio.ReadWriter returned by Hijack may still hold data the HTTP server read ahead; the returned conn
+// serves that data first and then reads through the limited path again. Pending buffered writes are flushed
+// before the next write.
+//
+// The read-ahead data was read through the LimitedConnection, so it was already charged to the limiters and
+// is not charged a second time.
+func (lc *LimitedConnection) HijackBuffered(rw *bufio.ReadWriter) net.Conn {
+	return &hijackedConn{Conn: lc, lc: lc, rw: rw}
+}
+
+// hijackedConn drains the buffers left by an HTTP hijack before using the LimitedConnection directly. Only the
+// net.Conn methods of the LimitedConnection are promoted, so no read or write path can skip the buffers.
+type hijackedConn struct {
+	net.Conn
+	lc *LimitedConnection
+	rw *bufio.ReadWriter
+}
+
+func (c *hijackedConn) Read(b []byte) (int, error) {
+	if c.rw.Reader.Buffered() > 0 {
+		return c.rw.Reader.Read(b)
+	}
+	return c.lc.Read(b)
+}
+
+func (c *hijackedConn) Write(b []byte) (int, error) {
+	if err := c.flush(); err != nil {
+		return 0, err
+	}
+	return c.lc.Write(b)
+}
+
+// ReadFrom flushes the pending buffered writes and then copies r through the LimitedConnection, see
+// LimitedConnection.ReadFrom.
+func (c *hijackedConn) ReadFrom(r io.Reader) (int64, error) {
+	if err := c.flush(); err != nil {
+		return 0, err
+	}
+	return c.lc.ReadFrom(r)
+}
+
+// WriteBuffers flushes the pending buffered writes and then writes bufs through the LimitedConnection, see
+// LimitedConnection.WriteBuffers.
+func (c *hijackedConn) WriteBuffers(bufs net.Buffers) (int64, error) {
+	if err := c.flush(); err != nil {
+		return 0, err
+	}
+	return c.lc.WriteBuffers(bufs)
+}
+
+// flush writes the data left in the buffered writer of the hijack, if any.
+func (c *hijackedConn) flush() error {
+	if c.rw.Writer.Buffered() > 0 {
+		return c.rw.Writer.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package limitedlistener
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestHijackBuffered verifies that data read ahead by the HTTP server before a hijack is served first and
+// charged exactly once, and that later reads go through the limited path.
+func TestHijackBuffered(t *testing.T) {
+	limitedListener, err := Listen("tcp", "127.0.0.1:0", WithLimits(1_000_000, 1_000_000))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	const request = "GET / HTTP/1.1\r\nHost: test\r\n\r\n"
+	const buffered = "buffered-payload"
+	const later = "later-payload"
+
+	result := make(chan string, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("hijack error: %v", err)
+			return
+		}
+		lc := conn.(*LimitedConnection)
+		c := lc.HijackBuffered(rw)
+		defer c.Close()
+
+		if rw.Reader.Buffered() != len(buffered) {
+			t.Errorf("expected %d buffered bytes, but got %d", len(buffered), rw.Reader.Buffered())
+		}
+
+		got := make([]byte, len(buffered)+len(later))
+		if _, err := io.ReadFull(c, got[:len(buffered)]); err != nil {
+			t.Errorf("read error: %v", err)
+		}
+		if _, err := c.Write([]byte("ok")); err != nil {
+			t.Errorf("write error: %v", err)
+		}
+		if _, err := io.ReadFull(c, got[len(buffered):]); err != nil {
+			t.Errorf("read error: %v", err)
+		}
+
+		if want := int64(len(request) + len(buffered) + len(later)); lc.BytesRead() != want {
+			t.Errorf("expected %d bytes charged, but got %d", want, lc.BytesRead())
+		}
+		result <- string(got)
+	})}
+	go server.Serve(limitedListener)
+	defer server.Close()
+
+	client, err := net.Dial("tcp", limitedListener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.Write([]byte(request + buffered)); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if _, err := io.ReadFull(client, make([]byte, 2)); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if _, err := client.Write([]byte(later)); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+
+	if got := <-result; got != buffered+later {
+		t.Errorf("expected %q, but got %q", buffered+later, got)
+	}
+}
+
+// TestHijackBufferedWriteOrder verifies that pending buffered writes go out before data written with io.Copy.
+func TestHijackBufferedWriteOrder(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 1_000_000, 1_000_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	lc := listener.Track(server)
+	rw := bufio.NewReadWriter(bufio.NewReader(lc), bufio.NewWriter(lc))
+	c := lc.HijackBuffered(rw)
+	defer c.Close()
+
+	go func() {
+		rw.WriteString("FIRST")
+		io.Copy(c, struct{ io.Reader }{strings.NewReader("SECOND")})
+		c.Write([]byte("THIRD"))
+	}()
+
+	const want = "FIRSTSECONDTHIRD"
+	got := make([]byte, len(want))
+	if _, err := io.ReadFull(client, got); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("expected %q, but got %q", want, got)
+	}
+}
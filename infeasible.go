@@ -0,0 +1,41 @@
+package limitedlistener
+
+import "golang.org/x/time/rate"
+
+// WithOnInfeasibleConfig calls fn with the oversubscription ratio whenever a change of the limits leaves the
+// per-connection limits of the tracked connections summing to more than the global limit, for example with
+// WithPerConnPercent and many active connections. The connections then share the global limit and can't all
+// reach their own limit; the event is only informational and nothing is changed. A ratio of 2 means the
+// per-connection limits add up to twice the global limit. It runs on the goroutine changing the limits, which
+// holds the limits lock, so it must not change the limits itself.
+func WithOnInfeasibleConfig(fn func(ratio float64)) Option {
+	return func(o *options) {
+		o.onInfeasible = fn
+	}
+}
+
+// oversubscription returns the sum of the per-connection limits of the tracked connections divided by the global
+// limit, or zero without a global limit. Connections without a per-connection limit are not counted.
+func (l *LimitedListener) oversubscription() float64 {
+	global := l.globalReadLimiter.Limit()
+	if global == rate.Inf || global <= 0 {
+		return 0
+	}
+	var sum float64
+	for _, connection := range l.trackedConnections() {
+		connection.mu.Lock()
+		sum += float64(connection.bytesPerSecond)
+		connection.mu.Unlock()
+	}
+	return sum / float64(global)
+}
+
+// reportInfeasibleConfig calls the OnInfeasibleConfig callback if the per-connection limits oversubscribe the global one.
+func (l *LimitedListener) reportInfeasibleConfig() {
+	if l.opts.onInfeasible == nil {
+		return
+	}
+	if ratio := l.oversubscription(); ratio > 1 {
+		l.opts.onInfeasible(ratio)
+	}
+}
@@ -0,0 +1,35 @@
+package limitedlistener
+
+import (
+	"math"
+	"net"
+	"testing"
+)
+
+// TestOnInfeasibleConfig verifies that the event fires with the oversubscription ratio when the per-connection
+// limits add up to more than the global limit, and not otherwise.
+func TestOnInfeasibleConfig(t *testing.T) {
+	var ratios []float64
+	listener, err := NewLimitedListener(nil, 1_000, 100, WithOnInfeasibleConfig(func(ratio float64) {
+		ratios = append(ratios, ratio)
+	}))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		server, client := net.Pipe()
+		defer client.Close()
+		defer listener.Track(server).Close()
+	}
+
+	listener.SetLimits(1_000, 300)
+	if len(ratios) != 0 {
+		t.Fatalf("expected no event for per-connection limits below the global one, got %v", ratios)
+	}
+
+	listener.SetLimits(1_000, 600)
+	if len(ratios) != 1 || math.Abs(ratios[0]-1.8) > 1e-9 {
+		t.Errorf("expected a single event with a ratio of 1.8, got %v", ratios)
+	}
+}
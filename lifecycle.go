@@ -0,0 +1,47 @@
+package limitedlistener
+
+import (
+	"context"
+	"net"
+)
+
+// NewLimitedListenerContext wraps listener like NewLimitedListener, taking the limits from the WithLimits option,
+// and binds its lifetime to ctx: once ctx is cancelled the listener is shut down. The limiter waits of its
+// connections derive from ctx too, so blocked reads and writes return as soon as it is cancelled.
+func NewLimitedListenerContext(ctx context.Context, listener net.Listener, opts ...Option) (*LimitedListener, error) {
+	o := newOptions(opts)
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	l, err := newLimitedListener(listener, o)
+	if err != nil {
+		return nil, err
+	}
+	l.ctx = ctx
+	l.stopContext = context.AfterFunc(ctx, func() {
+		l.Shutdown()
+	})
+
+	return l, nil
+}
+
+// Shutdown stops accepting new connections, closes the underlying listener and closes every tracked connection.
+// It returns the error of closing the underlying listener.
+func (l *LimitedListener) Shutdown() error {
+	l.StopAccepting()
+
+	err := l.Close()
+	l.closeConnections()
+
+	return err
+}
+
+// WithOnIdle sets a function called when the last tracked connection is removed and no accepted connection is
+// pending in Accept, for example to start a spin-down timer. It runs on the goroutine closing the connection,
+// after the listener lock has been released.
+func WithOnIdle(fn func()) Option {
+	return func(o *options) {
+		o.onIdle = fn
+	}
+}
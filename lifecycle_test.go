@@ -0,0 +1,152 @@
+package limitedlistener
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestNewLimitedListenerContext verifies that cancelling the context stops the listener and closes its connections.
+func TestNewLimitedListenerContext(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	limitedListener, err := NewLimitedListenerContext(ctx, listener, WithLimits(100, 100))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	defer client.Close()
+
+	conn, err := limitedListener.Accept()
+	if err != nil {
+		t.Fatalf("accept error: %v", err)
+	}
+	defer conn.Close()
+
+	readErr := make(chan error)
+	go func() {
+		_, err := conn.Read(make([]byte, 10))
+		readErr <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-readErr:
+		if !errors.Is(err, net.ErrClosed) {
+			t.Errorf("expected the connection to be closed, but got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected the blocked read to return after cancelling the context")
+	}
+
+	if _, err := limitedListener.Accept(); err == nil {
+		t.Errorf("expected Accept to fail after cancelling the context")
+	}
+	if limitedListener.IsAccepting() {
+		t.Errorf("expected the listener to stop accepting")
+	}
+
+	// Shutdown runs on its own goroutine, give it a moment to finish the cleanup.
+	time.Sleep(50 * time.Millisecond)
+
+	limitedListener.mu.RLock()
+	defer limitedListener.mu.RUnlock()
+	if len(limitedListener.connections) != 0 {
+		t.Errorf("expected 0 connections but got %d", len(limitedListener.connections))
+	}
+}
+
+// TestNewLimitedListenerContextClose verifies that cancelling the context after the listener was closed directly
+// no longer shuts it down, as Close releases the context.
+func TestNewLimitedListenerContextClose(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	limitedListener, err := NewLimitedListenerContext(ctx, pipeListener{}, WithLimits(100, 100))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	conn, err := limitedListener.Accept()
+	if err != nil {
+		t.Fatalf("accept error: %v", err)
+	}
+	defer conn.Close()
+
+	limitedListener.Close()
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+
+	if got := len(limitedListener.trackedConnections()); got != 1 {
+		t.Errorf("expected the connection to survive cancelling the context after Close, got %d connections", got)
+	}
+}
+
+// TestOnIdle verifies that the idle callback fires exactly once, when the last connection is closed.
+func TestOnIdle(t *testing.T) {
+	var idle atomic.Int32
+	listener, err := NewLimitedListener(pipeListener{}, 1_000, 1_000, WithOnIdle(func() {
+		idle.Add(1)
+	}))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	var conns []net.Conn
+	for i := 0; i < 3; i++ {
+		conn, err := listener.Accept()
+		if err != nil {
+			t.Fatalf("accept error: %v", err)
+		}
+		conns = append(conns, conn)
+	}
+
+	for _, conn := range conns {
+		if got := idle.Load(); got != 0 {
+			t.Fatalf("expected no idle callback while connections are open, got %d", got)
+		}
+		conn.Close()
+	}
+	conns[0].Close()
+
+	if got := idle.Load(); got != 1 {
+		t.Errorf("expected the idle callback to fire once, got %d", got)
+	}
+}
+
+// TestOnIdlePendingAccept verifies that the idle callback doesn't fire while an accepted connection is pending.
+func TestOnIdlePendingAccept(t *testing.T) {
+	var idle atomic.Int32
+	listener, err := NewLimitedListener(pipeListener{}, 1_000, 1_000, WithOnIdle(func() {
+		idle.Add(1)
+	}))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("accept error: %v", err)
+	}
+
+	listener.pendingAccepts.Add(1)
+	conn.Close()
+	if got := idle.Load(); got != 0 {
+		t.Errorf("expected no idle callback while an accept is pending, got %d", got)
+	}
+}
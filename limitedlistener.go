@@ -1,90 +1,627 @@
 // Package limitedlistener provides a TCP listener that enforces global and per-connection bandwidth limits on data transfer.
 // It uses the `golang.org/x/time/rate` package to implement rate limiting and ensures that the bandwidth consumed by all connections
 // stays within the specified limits.
+//
+// The throttling core only depends on net.Listener and net.Conn, so it works for any stream transport, such as
+// Unix sockets, in-memory pipes or QUIC streams adapted to net.Conn. Connections of other transports can be
+// throttled on their own with WrapStreamConn. Transport-specific methods of the wrapped connection, like stream
+// IDs, stay reachable through the embedded Conn field.
 package limitedlistener
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"net"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"golang.org/x/sync/semaphore"
 	"golang.org/x/time/rate"
 )
 
 var (
 	ErrLimitOutOfRange = fmt.Errorf("bandwidth limits must be higher than zero")
 	ErrInvalidLimits   = fmt.Errorf("global bandwidth limit must be equal or higher than per conn bandwidth limit")
+	ErrNotAccepting    = fmt.Errorf("listener is not accepting new connections")
+	ErrNilConn         = fmt.Errorf("underlying listener returned a nil connection without an error")
 )
 
-// LimitedConnection wraps a net.Conn and enforces both global and per-connection bandwidth limits on the Read operation.
+// LimitedConnection wraps a net.Conn and enforces both global and per-connection bandwidth limits on the Read operation,
+// and the global write bandwidth limit on the Write operation when one is configured.
 type LimitedConnection struct {
 	net.Conn
-	globalLimiter  *rate.Limiter
-	limiter        *rate.Limiter
-	parentListener *LimitedListener
+	globalReadLimiter   atomic.Pointer[rate.Limiter]
+	globalWriteLimiter  atomic.Pointer[rate.Limiter]
+	limiter             atomic.Pointer[rate.Limiter]
+	ceilingLimiter      atomic.Pointer[rate.Limiter]
+	parentListener      atomic.Pointer[LimitedListener]
+	extraLimiters       atomic.Pointer[[]*rate.Limiter]
+	bytesRead           atomic.Int64
+	bytesRequested      atomic.Int64
+	bytesWritten        atomic.Int64
+	logicalBytesWritten atomic.Int64
+	waitTime            atomic.Int64
+	burstCredit         atomic.Int64
+	firstByteTimer      atomic.Pointer[time.Timer]
+	noFirstByte         atomic.Bool
+	pinned              atomic.Bool
+	reading             atomic.Bool
+	readMu              sync.Mutex
+	rateBound           rateBoundTracker
+	budget              intervalBudget
+	tenant              string
+	id                  uint64
+	listenerAddr        net.Addr
+	unlimited           bool
+	readDeadline        atomic.Int64
+	writeDeadline       atomic.Int64
+	createdAt           time.Time
+	throughput          *throughputRing
+	readThroughput      *throughputRing
+	priority            *priorityPrefix
+	closing             chan struct{}
+	closeOnce           sync.Once
+	closeErr            error
+
+	// mu guards bytesPerSecond, readSizeAverage, the lazy creation of limiter and updates of extraLimiters.
+	mu              sync.Mutex
+	bytesPerSecond  int
+	readSizeAverage float64
 }
 
 // newLimitedConnection creates a new LimitedConnection with the specified global and per-connection bandwidth limits.
 //
 // Parameters:
 //   - conn: The underlying net.Conn to wrap.
-//   - globalLimiter: The global rate limiter shared across all connections.
-//   - bytesPerSecond: The per-connection bandwidth limit in bytes per second.
+//   - globalLimiter: The global read rate limiter shared across all connections.
+//   - bytesPerSecond: The per-connection bandwidth limit in bytes per second, or zero for no limit.
 //   - parentListener: Reference to the parent listener used for cleanup when the connection closes.
+//
+// Writes are throttled by the parent listener's global write limiter, or not at all without a parent.
+// The per-connection limiter is not allocated here but on the first Read, so idle connections stay cheap.
 func newLimitedConnection(conn net.Conn, globalLimiter *rate.Limiter, bytesPerSecond int, parentListener *LimitedListener) *LimitedConnection {
-	limiter := rate.NewLimiter(rate.Limit(bytesPerSecond), bytesPerSecond)
-	return &LimitedConnection{
+	lc := &LimitedConnection{
 		Conn:           conn,
-		globalLimiter:  globalLimiter,
-		limiter:        limiter,
-		parentListener: parentListener,
+		bytesPerSecond: bytesPerSecond,
+		createdAt:      time.Now(),
+		closing:        make(chan struct{}),
+	}
+	if parentListener != nil && parentListener.opts.samples > 0 {
+		lc.throughput = newThroughputRing(parentListener.opts.samples)
+		lc.readThroughput = newThroughputRing(parentListener.opts.samples)
+	}
+	lc.globalReadLimiter.Store(globalLimiter)
+	if parentListener != nil {
+		lc.globalWriteLimiter.Store(parentListener.globalWriteLimiter)
+	} else {
+		lc.globalWriteLimiter.Store(newLimiter(0))
+	}
+	lc.parentListener.Store(parentListener)
+	return lc
+}
+
+// WrapStreamConn throttles a single connection that is not accepted through a LimitedListener, for example a
+// stream of a multiplexed transport. Reads wait on globalLimiter, which may be shared between connections or be
+// nil for no shared limit, and on a per-connection limit of bytesPerSecond, where zero means unlimited.
+// Writes are not throttled.
+func WrapStreamConn(conn net.Conn, globalLimiter *rate.Limiter, bytesPerSecond int) *LimitedConnection {
+	if globalLimiter == nil {
+		globalLimiter = newLimiter(0)
+	}
+	return newLimitedConnection(conn, globalLimiter, bytesPerSecond, nil)
+}
+
+// perConnLimiter returns the per-connection limiter, creating it on first use.
+func (lc *LimitedConnection) perConnLimiter() *rate.Limiter {
+	if limiter := lc.limiter.Load(); limiter != nil {
+		return limiter
+	}
+
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	if lc.limiter.Load() == nil {
+		if ceiling := lc.options().workConservingCeiling; ceiling > 0 && !lc.unlimited {
+			lc.ceilingLimiter.Store(newLimiter(ceiling))
+		}
+		lc.limiter.Store(newLimiter(lc.bytesPerSecond))
+	}
+	return lc.limiter.Load()
+}
+
+// setConnRate updates the per-connection limit of lc, applying it to the limiter if it was already created.
+// Every change of a per-connection limit goes through it, so the limit and the burst always move together.
+// It reports whether the limit changed.
+func setConnRate(lc *LimitedConnection, bytesPerSecond int) bool {
+	return setConnRateAt(lc, bytesPerSecond, time.Now())
+}
+
+// setConnRateAt is setConnRate with the token effects of the change computed at t. Connections exempted from the
+// limits by WithLimitPredicate are left unlimited.
+func setConnRateAt(lc *LimitedConnection, bytesPerSecond int, t time.Time) bool {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	if lc.unlimited {
+		return false
 	}
+	changed := lc.bytesPerSecond != bytesPerSecond
+	lc.bytesPerSecond = bytesPerSecond
+	if limiter := lc.limiter.Load(); limiter != nil {
+		setLimiterRateAt(limiter, bytesPerSecond, t)
+	}
+	return changed
 }
 
 // Read reads data from the connection while respecting the global and per-connection bandwidth limits.
 // It ensures that the data transfer rate does not exceed the specified limits.
+//
+// The underlying read is performed first, sized to the per-connection burst, and only the bytes actually
+// read are then charged to the limiters. This keeps the sustained throughput on the configured rate
+// regardless of how short the underlying reads are.
+// A read that returns no data, such as a would-block error on a nonblocking socket, consumes no rate budget.
+// A zero-length buffer returns (0, nil) without touching the limiters or the underlying connection.
+// With WithReadOverhead, every read is charged the configured overhead on top of the bytes read.
 func (lc *LimitedConnection) Read(b []byte) (int, error) {
-	allowed := len(b)
-
-	ctx := context.Background()
+	return lc.timedRead(lc.context(), b)
+}
 
-	if allowed > lc.limiter.Burst() {
-		allowed = lc.limiter.Burst()
+// read implements Read and ReadContext, waiting on the limiters with ctx.
+func (lc *LimitedConnection) read(ctx context.Context, b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
 	}
-	err := lc.globalLimiter.WaitN(ctx, allowed)
+	done, err := lc.enterRead()
 	if err != nil {
-		return 0, fmt.Errorf("global: %v", err)
+		return 0, err
 	}
+	defer done()
 
-	// Re-check the burst capacity of the rate limiter, as it may have changed since the last WaitN call.
-	if allowed > lc.limiter.Burst() {
-		allowed = lc.limiter.Burst()
+	release, err := lc.acquireReadSlot(ctx)
+	if err != nil {
+		return 0, err
 	}
-	err = lc.limiter.WaitN(ctx, allowed)
+	defer release()
+
+	addSaturating(&lc.bytesRequested, int64(len(b)))
+	if lc.options().autoBurst {
+		lc.observeReadSize(len(b))
+	}
+	if err := lc.checkConnQuota(); err != nil {
+		return 0, err
+	}
+	budget, err := lc.waitBudget(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("local: %v", err)
+		return 0, err
+	}
+	b = b[:min(int64(len(b)), budget)]
+
+	// Waited for before the underlying read as well, so a paused listener doesn't pull data from the socket; the
+	// checks after the reads catch a pause starting while one is in progress.
+	if err := lc.waitResumed(ctx, &lc.readDeadline); err != nil {
+		return 0, err
+	}
+
+	if remaining, ok := lc.warmupRemaining(); ok {
+		n, err := lc.readConn(b[:min(int64(len(b)), remaining)])
+		if n <= 0 {
+			return n, err
+		}
+		lc.recordRead(n)
+		lc.observeRead(b[:n])
+		if perr := lc.waitResumed(ctx, &lc.readDeadline); perr != nil {
+			return n, perr
+		}
+		return n, err
+	}
+
+	if lc.measureOnly() {
+		n, err := lc.readConn(b)
+		if n <= 0 {
+			return n, err
+		}
+		lc.recordRead(n)
+		lc.observeRead(b[:n])
+		if perr := lc.waitResumed(ctx, &lc.readDeadline); perr != nil {
+			return n, perr
+		}
+		return n, err
+	}
+
+	if err := lc.checkWaiters(); err != nil {
+		return 0, err
+	}
+
+	var buf [4]*rate.Limiter
+	limiters := lc.limiters(buf[:0])
+
+	opts := lc.options()
+	allowed := len(b)
+	if quantum := opts.roundRobinQuantum; quantum > 0 {
+		allowed = max(opts.fitRead(quantum, allowed), 1)
+	}
+	for _, limiter := range limiters {
+		allowed = max(opts.fitRead(maxChunk(limiter, opts.readTokens(allowed)), allowed), 1)
+	}
+	atLeast := 1
+	if minSize := opts.minReadSize; minSize > 0 {
+		atLeast = min(minSize, len(b))
+		allowed = max(allowed, atLeast)
+	}
+
+	if maxWait := opts.maxReadWait; maxWait > 0 && allowed > 0 {
+		allowed = opts.fitRead(affordableWithin(maxWait, opts.readTokens(allowed), limiters...), allowed)
+		if allowed == 0 {
+			return 0, lc.waitReadCap(ctx, maxWait)
+		}
+	}
+
+	n, err := lc.readAtLeast(b[:allowed], min(atLeast, allowed))
+	if n <= 0 {
+		return n, err
+	}
+	lc.recordRead(n)
+	lc.observeRead(b[:n])
+
+	if perr := lc.waitResumed(ctx, &lc.readDeadline); perr != nil {
+		return n, perr
+	}
+
+	waitStart := time.Now()
+	defer func() { lc.observeWait(ctx, "read", n, lc.recordWait(waitStart)) }()
+	defer lc.trackWaiter()()
+
+	charge := opts.readTokens(n)
+	for i := range limiters {
+		if werr := lc.withWaitPolicy(ctx, func() error { return lc.waitChain(ctx, limiters, i, charge) }); werr != nil {
+			return n, fmt.Errorf("%s: %w", limiterName(i), werr)
+		}
 	}
 
-	return lc.Conn.Read(b[:allowed])
+	return n, err
+}
+
+// waitChain charges n bytes to the limiter at position i of the chain returned by limiters.
+func (lc *LimitedConnection) waitChain(ctx context.Context, limiters []*rate.Limiter, i, n int) error {
+	switch i {
+	case 0:
+		return lc.waitGlobal(ctx, limiters[0], n)
+	case 1:
+		n -= lc.takeBurstCredit(n)
+		start := time.Now()
+		if err := lc.waitPerConn(ctx, limiters[1], limiters[0], n); err != nil {
+			return err
+		}
+		lc.observePerConnWait(time.Since(start))
+		return nil
+	default:
+		return waitN(ctx, limiters[i], n)
+	}
+}
+
+// limiters appends the limiters a transfer waits on to dst, in order: global, per-connection, then the ones added with AddLimiter.
+func (lc *LimitedConnection) limiters(dst []*rate.Limiter) []*rate.Limiter {
+	dst = append(dst, lc.globalReadLimiter.Load(), lc.perConnLimiter())
+	if extra := lc.extraLimiters.Load(); extra != nil {
+		dst = append(dst, *extra...)
+	}
+	return dst
+}
+
+// limiterName names the limiter at position i of the chain returned by limiters, for error messages.
+func limiterName(i int) string {
+	switch i {
+	case 0:
+		return "global"
+	case 1:
+		return "local"
+	default:
+		return fmt.Sprintf("limiter %d", i-1)
+	}
+}
+
+// AddLimiter attaches an additional limiter to the connection. Reads wait on it after the global and
+// per-connection limiters, so the tightest limiter in the chain bounds the transfer. The same limiter
+// can be shared between connections to enforce a common budget, e.g. per client IP or per group.
+// Adding a limiter that is already attached has no effect.
+func (lc *LimitedConnection) AddLimiter(limiter *rate.Limiter) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	var extra []*rate.Limiter
+	if current := lc.extraLimiters.Load(); current != nil {
+		if slices.Contains(*current, limiter) {
+			return
+		}
+		extra = append(extra, *current...)
+	}
+	extra = append(extra, limiter)
+	lc.extraLimiters.Store(&extra)
+}
+
+// removeLimiter detaches a limiter attached with AddLimiter.
+func (lc *LimitedConnection) removeLimiter(limiter *rate.Limiter) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	current := lc.extraLimiters.Load()
+	if current == nil {
+		return
+	}
+	extra := make([]*rate.Limiter, 0, len(*current))
+	for _, attached := range *current {
+		if attached != limiter {
+			extra = append(extra, attached)
+		}
+	}
+	lc.extraLimiters.Store(&extra)
+}
+
+// options returns the options of the listener owning the connection, or the defaults if it has none.
+func (lc *LimitedConnection) options() *options {
+	if parent := lc.parentListener.Load(); parent != nil {
+		return &parent.opts
+	}
+	return &defaultOptions
+}
+
+// Write writes data to the connection while respecting the global write bandwidth limit.
+// The data is written in burst-sized chunks, each charged to the limiter before it is written.
+// Without a global write limit the data is passed to the underlying connection as is.
+func (lc *LimitedConnection) Write(b []byte) (int, error) {
+	ctx := lc.context()
+	if timeout := lc.options().operationTimeout; timeout > 0 {
+		ctx, finish := lc.startOperation(ctx, timeout, &lc.writeDeadline, lc.Conn.SetWriteDeadline)
+		n, err := lc.write(ctx, b)
+		return n, finish(err)
+	}
+	return lc.write(ctx, b)
+}
+
+// write implements Write, waiting on the limiter with ctx.
+func (lc *LimitedConnection) write(ctx context.Context, b []byte) (int, error) {
+	if err := lc.checkConnQuota(); err != nil {
+		return 0, err
+	}
+
+	if remaining, ok := lc.warmupRemaining(); ok {
+		if err := lc.waitResumed(ctx, &lc.writeDeadline); err != nil {
+			return 0, err
+		}
+		n, err := lc.Conn.Write(b[:min(int64(len(b)), remaining)])
+		lc.recordWritten(n)
+		if err != nil || n == len(b) {
+			return n, err
+		}
+		rest, err := lc.write(ctx, b[n:])
+		return n + rest, err
+	}
+
+	if lc.writesUnlimited() && !lc.paused() {
+		n, err := lc.Conn.Write(b)
+		lc.recordWritten(n)
+		return n, err
+	}
+
+	if lc.measureOnly() {
+		if err := lc.waitResumed(ctx, &lc.writeDeadline); err != nil {
+			return 0, err
+		}
+		n, err := lc.Conn.Write(b)
+		lc.recordWritten(n)
+		return n, err
+	}
+
+	var buf [2]*rate.Limiter
+	limiters := lc.writeLimiters(buf[:0])
+	opts := lc.options()
+
+	written := 0
+	var waited time.Duration
+	defer func() { lc.observeWait(ctx, "write", written, waited) }()
+
+	for written < len(b) {
+		if err := lc.waitResumed(ctx, &lc.writeDeadline); err != nil {
+			return written, err
+		}
+
+		chunk := len(b) - written
+		for _, limiter := range limiters {
+			chunk = max(opts.fitWrite(maxChunk(limiter, opts.tokens(chunk)), chunk), 1)
+		}
+		charge := opts.tokens(chunk)
+		start := time.Now()
+		var err error
+		for i := 0; i < len(limiters) && err == nil; i++ {
+			if err = lc.withWaitPolicy(ctx, func() error { return waitN(ctx, limiters[i], charge) }); err != nil {
+				err = fmt.Errorf("%s: %w", writeLimiterName(i), err)
+			}
+		}
+		waited += lc.recordWait(start)
+		if err != nil {
+			return written, err
+		}
+
+		n, err := lc.Conn.Write(b[written : written+chunk])
+		lc.recordWritten(n)
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// context returns the base context of the listener owning the connection, used for the limiter waits.
+func (lc *LimitedConnection) context() context.Context {
+	if parent := lc.parentListener.Load(); parent != nil {
+		return parent.ctx
+	}
+	return context.Background()
+}
+
+// recordWait adds the time spent waiting on limiters since start to the connection's wait time and returns it.
+func (lc *LimitedConnection) recordWait(start time.Time) time.Duration {
+	wait := time.Since(start)
+	addSaturating(&lc.waitTime, int64(wait))
+	return wait
+}
+
+// recordRead adds n read bytes to the connection and listener counters.
+func (lc *LimitedConnection) recordRead(n int) {
+	lc.disarmFirstByteTimer()
+	lc.spendBudget(n)
+	addSaturating(&lc.bytesRead, int64(n))
+	if parent := lc.parentListener.Load(); parent != nil {
+		addSaturating(&parent.bytesRead, int64(n))
+		parent.recordBytes(n)
+	}
+}
+
+// recordWritten adds n written bytes to the connection and listener counters.
+func (lc *LimitedConnection) recordWritten(n int) {
+	if n <= 0 {
+		return
+	}
+	addSaturating(&lc.bytesWritten, int64(n))
+	if parent := lc.parentListener.Load(); parent != nil {
+		parent.recordBytes(n)
+	}
+}
+
+// PinLimit exempts the connection from the per-connection limit changes made by SetLimits and ScaleLimits
+// when pinned is true, for example to protect connections with an SLA from fleet-wide reductions.
+// The connection keeps its current per-connection limit until it is unpinned; the global limit still applies.
+// Unpinning does not restore the listener's limit, it is applied by the next SetLimits call.
+func (lc *LimitedConnection) PinLimit(pinned bool) {
+	lc.pinned.Store(pinned)
+}
+
+// Limiter returns the per-connection rate limiter. It is an escape hatch for tuning not covered by this package,
+// such as inspecting or pre-draining tokens; rate.Limiter is safe for concurrent use.
+// Limits changed through it are overwritten by the next SetLimits call.
+func (lc *LimitedConnection) Limiter() *rate.Limiter {
+	return lc.perConnLimiter()
+}
+
+// ID returns the identifier assigned to the connection by Accept. IDs start at 1 and increase with every
+// connection accepted by the listener, so they can be used to correlate logs and metrics; connections not
+// accepted through a listener, such as those wrapped with WrapStreamConn, have the ID 0.
+func (lc *LimitedConnection) ID() uint64 {
+	return lc.id
+}
+
+// LocalListenerAddr returns the address of the listener that accepted the connection, which tells apart the
+// listeners of a process listening on several addresses. It is nil for connections not accepted through a listener.
+func (lc *LimitedConnection) LocalListenerAddr() net.Addr {
+	return lc.listenerAddr
+}
+
+// BytesRead returns the number of bytes read from the connection so far.
+func (lc *LimitedConnection) BytesRead() int64 {
+	return lc.bytesRead.Load()
+}
+
+// BytesRequested returns the sum of the buffer sizes passed to Read so far. Compared with BytesRead it tells how well
+// the caller's buffers fit the limits: reads are capped at the burst, so a requested count much larger than the read
+// count means the buffers are oversized, while a close match means they are no larger than needed.
+func (lc *LimitedConnection) BytesRequested() int64 {
+	return lc.bytesRequested.Load()
+}
+
+// BytesWritten returns the number of bytes written to the connection so far.
+func (lc *LimitedConnection) BytesWritten() int64 {
+	return lc.bytesWritten.Load()
+}
+
+// AvailableTokens returns the number of bytes the per-connection limiter can grant right now, so a handler can
+// size its next chunk to avoid blocking. The value is negative while earlier reads are still being paid off,
+// and +Inf if the connection has no per-connection limit. Credit granted with GrantBurst is included.
+func (lc *LimitedConnection) AvailableTokens() float64 {
+	limiter := lc.perConnLimiter()
+	if limiter.Limit() == rate.Inf {
+		return math.Inf(1)
+	}
+	return limiter.TokensAt(time.Now()) + float64(lc.burstCredit.Load())
+}
+
+// waitN blocks until the limiter grants n tokens. The request is split into burst-sized chunks,
+// as the burst may have been lowered by SetLimits after the bytes were read.
+func waitN(ctx context.Context, limiter *rate.Limiter, n int) error {
+	for n > 0 {
+		chunk := max(maxChunk(limiter, n), 1)
+		if err := limiter.WaitN(ctx, chunk); err != nil {
+			// The burst was lowered between sizing the chunk and waiting; size it again.
+			if burst := limiter.Burst(); burst >= 1 && burst < chunk && ctx.Err() == nil {
+				continue
+			}
+			return err
+		}
+		n -= chunk
+	}
+	return nil
 }
 
 // Close closes the connection and notifies the listener to remove it from the connections map.
+// It is idempotent: only the first call closes the underlying connection and removes it from the listener,
+// and every call returns the error of the underlying close. The removal happens even if the underlying close
+// fails or panics.
 func (lc *LimitedConnection) Close() error {
-	err := lc.Conn.Close()
-	if lc.parentListener != nil {
-		lc.parentListener.removeConnection(lc)
-	}
-	return err
+	lc.closeOnce.Do(func() {
+		close(lc.closing)
+		lc.disarmFirstByteTimer()
+		defer func() {
+			if parent := lc.parentListener.Load(); parent != nil {
+				parent.removeConnection(lc)
+			}
+		}()
+		lc.closeErr = lc.Conn.Close()
+	})
+	return lc.closeErr
 }
 
 // LimitedListener wraps a net.Listener and enforces global and per-connection bandwidth limits on all accepted connections.
 type LimitedListener struct {
 	net.Listener
-	globalLimiter         *rate.Limiter
+	globalReadLimiter     *rate.Limiter
+	globalWriteLimiter    *rate.Limiter
 	perConnBandwidthLimit int
 	connections           map[*LimitedConnection]struct{}
-	sync.RWMutex
+	totalBytes            atomic.Int64
+	bytesRead             atomic.Int64
+	accepted              atomic.Int64
+	closed                atomic.Int64
+	notAccepting          atomic.Bool
+	measureOnly           atomic.Bool
+	pendingAccepts        atomic.Int64
+	blockedWaiters        atomic.Int64
+	lastID                atomic.Uint64
+	rejected              rejectCounters
+	scheduler             *roundRobinScheduler
+	readSlots             *semaphore.Weighted
+	tenantMu              sync.Mutex
+	tenants               map[string]*tenantGroup
+	tenantLimits          map[string]int
+	acceptLimiter         *rate.Limiter
+	ctx                   context.Context
+	stopContext           func() bool
+	done                  chan struct{}
+	closeOnce             sync.Once
+	setLimitsMu           sync.Mutex
+	pauseMu               sync.Mutex
+	utilization           utilizationSampler
+	throughput            *throughputRing
+	readThroughput        *throughputRing
+	acceptTracker         acceptTracker
+	resumed               chan struct{}
+	opts                  options
+
+	// mu guards connections, perConnBandwidthLimit and the limiter updates made by SetLimits and Adopt.
+	mu sync.RWMutex
 }
 
 // NewLimitedListener creates a new LimitedListener with the specified global and per-connection bandwidth limits.
@@ -92,64 +629,521 @@ type LimitedListener struct {
 // Parameters:
 //   - listener: The underlying net.Listener to wrap.
 //   - globalLimit: The global bandwidth limit in bytes per second.
-//   - perConnLimit: The per-connection bandwidth limit in bytes per second.
-func NewLimitedListener(listener net.Listener, globalLimit, perConnLimit int) (*LimitedListener, error) {
+//   - perConnLimit: The per-connection bandwidth limit in bytes per second, ignored with WithPerConnPercent.
+//   - opts: Optional settings applied to the listener. Limits set by options, such as WithLimits or
+//     WithPerConnBitsPerSecond, replace the positional ones.
+func NewLimitedListener(listener net.Listener, globalLimit, perConnLimit int, opts ...Option) (*LimitedListener, error) {
+	o := newOptions(append([]Option{WithLimits(globalLimit, perConnLimit)}, opts...))
+	if err := validateLimits(o.globalLimit, perConnFor(&o, o.globalLimit, o.perConnLimit)); err != nil {
+		return nil, err
+	}
+
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	return newLimitedListener(listener, o)
+}
+
+// Listen announces on the local network address and wraps the resulting listener in a LimitedListener.
+// The limits are taken from the WithLimits option; without it the listener does not throttle connections.
+// The socket is created with the net.ListenConfig set by WithListenConfig, if any.
+func Listen(network, address string, opts ...Option) (*LimitedListener, error) {
+	o := newOptions(opts)
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
 
-	if globalLimit <= 0 || perConnLimit <= 0 {
-		return nil, ErrLimitOutOfRange
+	var listenConfig net.ListenConfig
+	if o.listenConfig != nil {
+		listenConfig = *o.listenConfig
 	}
-	if globalLimit < perConnLimit {
-		return nil, ErrInvalidLimits
+	listener, err := listenConfig.Listen(context.Background(), network, address)
+	if err != nil {
+		return nil, err
 	}
 
-	globalLimiter := rate.NewLimiter(rate.Limit(globalLimit), globalLimit)
+	return newLimitedListener(listener, o)
+}
+
+// newLimitedListener wraps listener using the already validated options.
+func newLimitedListener(listener net.Listener, o options) (*LimitedListener, error) {
+	var acceptLimiter *rate.Limiter
+	if o.acceptRate > 0 {
+		acceptLimiter = rate.NewLimiter(rate.Limit(o.acceptRate), max(o.acceptBurst, 1))
+	}
+
+	globalReadLimiter := o.globalLimiter
+	if globalReadLimiter == nil {
+		globalReadLimiter = newLimiter(o.globalLimit)
+	}
 
-	return &LimitedListener{
+	l := &LimitedListener{
 		Listener:              listener,
-		globalLimiter:         globalLimiter,
-		perConnBandwidthLimit: perConnLimit,
+		acceptLimiter:         acceptLimiter,
+		globalReadLimiter:     globalReadLimiter,
+		globalWriteLimiter:    newLimiter(o.globalWriteLimit),
+		perConnBandwidthLimit: perConnFor(&o, o.globalLimit, o.perConnLimit),
 		connections:           make(map[*LimitedConnection]struct{}),
-	}, nil
+		opts:                  o,
+		ctx:                   context.Background(),
+		done:                  make(chan struct{}),
+	}
+	l.utilization.at = time.Now()
+	if o.samples > 0 {
+		l.throughput = newThroughputRing(o.samples)
+		l.readThroughput = newThroughputRing(o.samples)
+		go l.sampleThroughput()
+	}
+	if len(o.schedule) > 0 {
+		now := o.clock.Now()
+		l.applySchedule(now)
+		go l.runSchedule(now)
+	}
+	if o.controllerTarget > 0 && o.controllerWindow > 0 {
+		go l.runThroughputController()
+	}
+	if o.compactionInterval > 0 {
+		go l.runLimiterCompaction()
+	}
+	if o.rateDecay != nil {
+		go l.runRateDecay()
+	}
+	if o.roundRobinQuantum > 0 {
+		l.scheduler = newRoundRobinScheduler(l.globalReadLimiter, l.done)
+	}
+	if o.maxConcurrentReads > 0 {
+		l.readSlots = semaphore.NewWeighted(int64(o.maxConcurrentReads))
+	}
+	l.measureOnly.Store(o.measureOnly)
+
+	return l, nil
+}
+
+// perConnFor returns the per-connection limit to enforce along with global under the listener's options.
+func (l *LimitedListener) perConnFor(global, perConn int) int {
+	return perConnFor(&l.opts, global, perConn)
+}
+
+// perConnFor returns the per-connection limit to enforce along with global according to o: the one derived from
+// global with WithPerConnPercent, which takes precedence, or perConn clamped to global with WithClampPerConn,
+// or perConn as is.
+func perConnFor(o *options, global, perConn int) int {
+	if global == 0 {
+		return perConn
+	}
+	if o.perConnFraction != 0 {
+		return scaleLimit(global, o.perConnFraction)
+	}
+	if o.clampPerConn && perConn > global {
+		return global
+	}
+	return perConn
+}
+
+// validateLimits checks that both limits are positive and that the global limit is not lower than the per-connection one.
+func validateLimits(global, perConn int) error {
+	if global <= 0 || perConn <= 0 {
+		return ErrLimitOutOfRange
+	}
+	if global < perConn {
+		return ErrInvalidLimits
+	}
+	return nil
+}
+
+// newLimiter creates a rate limiter for bytesPerSecond with a burst of the same size.
+// A zero value creates a limiter that never throttles.
+func newLimiter(bytesPerSecond int) *rate.Limiter {
+	if bytesPerSecond == 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	return rate.NewLimiter(rate.Limit(bytesPerSecond), bytesPerSecond)
+}
+
+// setLimiterRate sets both the limit and the burst of the limiter to bytesPerSecond, where zero means no limit.
+// A finite limit always gets a burst of at least 1, so waits of a single byte can always be granted.
+func setLimiterRate(limiter *rate.Limiter, bytesPerSecond int) {
+	setLimiterRateAt(limiter, bytesPerSecond, time.Now())
+}
+
+// setLimiterRateAt is setLimiterRate with the tokens accumulated under the old limit computed up to t.
+func setLimiterRateAt(limiter *rate.Limiter, bytesPerSecond int, t time.Time) {
+	if bytesPerSecond <= 0 {
+		limiter.SetLimitAt(t, rate.Inf)
+		limiter.SetBurstAt(t, 0)
+		return
+	}
+	limiter.SetLimitAt(t, rate.Limit(bytesPerSecond))
+	limiter.SetBurstAt(t, bytesPerSecond)
+}
+
+// maxChunk returns how many bytes can be charged to the limiter in a single call.
+func maxChunk(limiter *rate.Limiter, n int) int {
+	if limiter.Limit() == rate.Inf {
+		return n
+	}
+	return min(n, limiter.Burst())
 }
 
 // Accept accepts incoming connections and wraps them with a LimitedConnection to enforce bandwidth limits.
+// Connections rejected by the accept filter, the maximum number of connections or the admission controller
+// are closed and Accept waits for the next one; the rejections are counted in RejectedStats. A misbehaving
+// underlying listener returning neither a connection nor an error makes Accept return ErrNilConn, and the errors
+// of the underlying listener are returned as an *AcceptError.
 func (l *LimitedListener) Accept() (net.Conn, error) {
-	conn, err := l.Listener.Accept()
-	if err != nil {
+	for {
+		if l.quotaExceeded() {
+			return nil, ErrQuotaExceeded
+		}
+
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, &AcceptError{Addr: l.Addr(), Err: err}
+		}
+		if conn == nil {
+			return nil, ErrNilConn
+		}
+
+		limitedConnection, err := l.admit(conn)
+		if errors.Is(err, errRejected) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		l.acceptTracker.record(time.Now())
+		if limitedConnection == nil {
+			return conn, nil
+		}
+		return limitedConnection, nil
+	}
+}
+
+// admit wraps and tracks a connection accepted from the underlying listener, or closes it if it may not be
+// handed out. It returns errRejected if Accept should move on to the next connection, and a nil connection
+// without error if conn should be handed out unwrapped, see WithBypassWrapperWhenUnlimited.
+func (l *LimitedListener) admit(conn net.Conn) (*LimitedConnection, error) {
+	l.pendingAccepts.Add(1)
+	defer l.pendingAccepts.Add(-1)
+
+	if filter := l.opts.acceptFilter; filter != nil && !filter(conn) {
+		conn.Close()
+		l.rejected.filter.Add(1)
+		return nil, errRejected
+	}
+
+	if err := l.waitAcceptRate(); err != nil {
+		// The listener is shutting down, which is no rejection to answer or count.
+		conn.Close()
 		return nil, err
 	}
 
-	l.RLock()
-	defer l.RUnlock()
+	if !l.waitAdmission() {
+		l.reject(conn)
+		l.rejected.admission.Add(1)
+		return nil, errRejected
+	}
+
+	if l.quotaExceeded() {
+		l.reject(conn)
+		l.rejected.quota.Add(1)
+		return nil, ErrQuotaExceeded
+	}
+	if !l.IsAccepting() {
+		l.reject(conn)
+		return nil, ErrNotAccepting
+	}
+
+	var listenerAddr net.Addr
+	if l.Listener != nil {
+		listenerAddr = l.Addr()
+	}
 
-	limitedConnection := newLimitedConnection(conn, l.globalLimiter, l.perConnBandwidthLimit, l)
-	l.connections[limitedConnection] = struct{}{}
+	tenant, limited := l.classify(conn)
+	decayed := l.initialDecayedRate()
+
+	l.mu.Lock()
+	if maxConns := l.opts.maxConnections; maxConns > 0 && len(l.connections) >= maxConns {
+		// The lock is released before the responder writes, so closing connections isn't stalled by a slow client.
+		l.mu.Unlock()
+		l.reject(conn)
+		l.rejected.maxConnections.Add(1)
+		return nil, errRejected
+	}
+	if l.bypassWrapperLocked() {
+		l.mu.Unlock()
+		return nil, nil
+	}
+
+	limitedConnection := l.trackLocked(conn, listenerAddr, tenant, limited, decayed)
+	if length := l.opts.priorityLength; length > 0 {
+		// Set before the connection is handed out, so it is in place for the first Read.
+		limitedConnection.priority = &priorityPrefix{length: length}
+		if limited {
+			limitedConnection.priority.fn = l.opts.priorityLimit
+		}
+	}
+	l.mu.Unlock()
+
+	if timeout := l.opts.firstByteTimeout; timeout > 0 {
+		limitedConnection.armFirstByteTimer(timeout)
+	}
 
 	return limitedConnection, nil
 }
 
+// classify returns the tenant of conn and whether the limits apply to it, as decided by the tenant and limit
+// predicate options. It calls user code, so it must be called without holding the listener lock.
+func (l *LimitedListener) classify(conn net.Conn) (tenant string, limited bool) {
+	if tenantOf := l.opts.tenantOf; tenantOf != nil {
+		tenant = tenantOf(conn)
+	}
+	return tenant, l.opts.limitPredicate == nil || l.opts.limitPredicate(conn)
+}
+
+// trackLocked wraps conn in a LimitedConnection with the listener's limits and adds it to the connections map.
+// decayed is the limit returned by initialDecayedRate, applied unless it is negative. The caller must hold mu.
+func (l *LimitedListener) trackLocked(conn net.Conn, listenerAddr net.Addr, tenant string, limited bool, decayed int) *LimitedConnection {
+	limitedConnection := newLimitedConnection(conn, l.globalReadLimiter, l.perConnBandwidthLimit, l)
+	limitedConnection.id = l.lastID.Add(1)
+	limitedConnection.listenerAddr = listenerAddr
+	if !limited {
+		limitedConnection.exemptFromLimits()
+	}
+	if decayed >= 0 {
+		setConnRate(limitedConnection, decayed)
+	}
+	if tenant != "" {
+		l.joinTenant(limitedConnection, tenant)
+	}
+	if !l.opts.untracked {
+		l.connections[limitedConnection] = struct{}{}
+	}
+	l.accepted.Add(1)
+	return limitedConnection
+}
+
+// Close closes the underlying listener and stops the background goroutines of the listener.
+// Connections that were already accepted are not closed, see Shutdown.
+func (l *LimitedListener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.done)
+		if l.stopContext != nil {
+			// Releases the callback of NewLimitedListenerContext, and with it ctx, when the listener is closed directly.
+			l.stopContext()
+		}
+	})
+	if l.Listener == nil {
+		return nil
+	}
+	return l.Listener.Close()
+}
+
+// GlobalLimiter returns the read rate limiter shared by all connections of the listener. It is an escape hatch for tuning
+// not covered by this package, such as SetLimitAt or inspecting Tokens; rate.Limiter is safe for concurrent use.
+// Limits changed through it are overwritten by the next SetLimits call.
+func (l *LimitedListener) GlobalLimiter() *rate.Limiter {
+	return l.globalReadLimiter
+}
+
+// GlobalWriteLimiter returns the write rate limiter shared by all connections of the listener.
+// Like GlobalLimiter it is an escape hatch for advanced tuning.
+func (l *LimitedListener) GlobalWriteLimiter() *rate.Limiter {
+	return l.globalWriteLimiter
+}
+
+// StopAccepting makes Accept close every new connection and return ErrNotAccepting, while connections
+// that were already accepted keep transferring at their configured rate. It is meant for draining an
+// instance during rolling deploys without shutting it down.
+func (l *LimitedListener) StopAccepting() {
+	l.notAccepting.Store(true)
+}
+
+// IsAccepting reports whether the listener still hands out new connections.
+func (l *LimitedListener) IsAccepting() bool {
+	return !l.notAccepting.Load()
+}
+
 // SetLimits updates the global and per-connection bandwidth limits for the listener and all active connections.
+// Concurrent calls are serialized, so the connections end up with the limits of the last call.
+// Invalid limits are ignored, except for a per-connection limit above the global one under WithClampPerConn.
+// Under WithStrictDownshift, lowering the global limit also scales down the tokens saved up in the global limiter.
 func (l *LimitedListener) SetLimits(global, perConn int) {
-	if global <= 0 || perConn <= 0 || global < perConn {
+	perConn = l.perConnFor(global, perConn)
+	if validateLimits(global, perConn) != nil {
+		return
+	}
+	l.setLimitsMu.Lock()
+	defer l.setLimitsMu.Unlock()
+
+	l.applyLimits(global, perConn)
+}
+
+// SetLimitsAt is SetLimits with the effect of the change on the limiter tokens computed at t rather than now:
+// the tokens accumulated under the old limits are counted up to t and the new limits apply from t onwards.
+// Paired with WithClock it lets tests check exactly how many tokens are available after a change without sleeping.
+func (l *LimitedListener) SetLimitsAt(t time.Time, global, perConn int) {
+	perConn = l.perConnFor(global, perConn)
+	if validateLimits(global, perConn) != nil {
 		return
 	}
-	l.Lock()
-	defer l.Unlock()
+	l.setLimitsMu.Lock()
+	defer l.setLimitsMu.Unlock()
+
+	l.applyLimitsAt(t, global, perConn)
+}
 
-	l.globalLimiter.SetLimit(rate.Limit(global))
-	l.globalLimiter.SetBurst(global)
+// applyLimits sets the global and per-connection limits without validating them. The caller must hold setLimitsMu.
+func (l *LimitedListener) applyLimits(global, perConn int) {
+	l.applyLimitsAt(time.Now(), global, perConn)
+}
+
+// applyLimitsAt is applyLimits with the token effects of the change computed at t.
+func (l *LimitedListener) applyLimitsAt(t time.Time, global, perConn int) {
+	perConn = l.perConnFor(global, perConn)
+
+	l.mu.Lock()
+	previous, tokens := l.globalReadLimiter.Limit(), l.globalReadLimiter.TokensAt(t)
+	setLimiterRateAt(l.globalReadLimiter, global, t)
+	if l.opts.strictDownshift {
+		scaleDownTokens(l.globalReadLimiter, previous, tokens, t)
+	}
 	l.perConnBandwidthLimit = perConn
+	l.mu.Unlock()
+
+	// The connections are updated outside the listener lock so accepts and closes aren't stalled while walking
+	// a large map. Connections accepted in the meantime already start with the new limit.
+	for _, connection := range l.trackedConnections() {
+		if connection.pinned.Load() {
+			continue
+		}
+		if setConnRateAt(connection, perConn, t) {
+			connection.notifyLimiterChange()
+		}
+	}
+	l.reportInfeasibleConfig()
+}
+
+// SetGlobalWriteLimit updates the global write bandwidth limit shared by all connections. Ingress is not affected.
+func (l *LimitedListener) SetGlobalWriteLimit(global int) {
+	if global <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	setLimiterRate(l.globalWriteLimiter, global)
+}
 
+// Adopt moves lc under this listener: it is tracked in the connections map, throttled by this listener's global limiter
+// and given this listener's per-connection limit. If lc still belongs to another listener it is released from it first.
+// Together with Release this allows replacing a listener without dropping its live connections. A connection that
+// is already closed is not tracked.
+func (l *LimitedListener) Adopt(lc *LimitedConnection) {
+	if parent := lc.parentListener.Load(); parent != nil && parent != l {
+		parent.Release(lc)
+	}
+
+	l.mu.Lock()
+	lc.parentListener.Store(l)
+	// Checked after taking ownership: a concurrent Close either closed lc before this check, or it sees this
+	// listener and removes lc once the lock is released.
+	select {
+	case <-lc.closing:
+		l.mu.Unlock()
+		return
+	default:
+	}
+
+	changed := false
+	if !lc.unlimited {
+		lc.globalReadLimiter.Store(l.globalReadLimiter)
+		lc.globalWriteLimiter.Store(l.globalWriteLimiter)
+		changed = setConnRate(lc, l.perConnBandwidthLimit)
+	}
+	if !l.opts.untracked {
+		l.connections[lc] = struct{}{}
+	}
+	l.mu.Unlock()
+
+	if changed {
+		lc.notifyLimiterChange()
+	}
+}
+
+// Release stops tracking lc without closing it, so it can be adopted by another listener.
+// It returns lc, or nil if the connection is not tracked by this listener.
+func (l *LimitedListener) Release(lc *LimitedConnection) *LimitedConnection {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.connections[lc]; !ok {
+		return nil
+	}
+	delete(l.connections, lc)
+	lc.parentListener.CompareAndSwap(l, nil)
+	if lc.tenant != "" {
+		l.leaveTenant(lc)
+		lc.tenant = ""
+	}
+
+	return lc
+}
+
+// TotalBytes returns the number of bytes transferred by all connections of the listener so far.
+func (l *LimitedListener) TotalBytes() int64 {
+	return l.totalBytes.Load()
+}
+
+// recordBytes adds n bytes to the listener total and enforces the byte quota when it is crossed.
+func (l *LimitedListener) recordBytes(n int) {
+	total := addSaturating(&l.totalBytes, int64(n))
+	quota := l.opts.totalByteQuota
+	if quota > 0 && l.opts.closeOnQuota && total >= quota && total-int64(n) < quota {
+		l.closeConnections()
+	}
+}
+
+// closeConnections closes every connection tracked by the listener.
+func (l *LimitedListener) closeConnections() {
+	for _, connection := range l.trackedConnections() {
+		connection.Close()
+	}
+}
+
+// trackedConnections returns a snapshot of the connections map, so callers can work on the connections without holding the lock.
+func (l *LimitedListener) trackedConnections() []*LimitedConnection {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	connections := make([]*LimitedConnection, 0, len(l.connections))
 	for connection := range l.connections {
-		connection.limiter.SetLimit(rate.Limit(perConn))
-		connection.limiter.SetBurst(perConn)
+		connections = append(connections, connection)
 	}
+	return connections
 }
 
 // removeConnection removes a connection from the connections map when it is closed.
 func (l *LimitedListener) removeConnection(lc *LimitedConnection) {
-	l.Lock()
-	defer l.Unlock()
+	if l.opts.untracked {
+		l.closed.Add(1)
+		return
+	}
 
-	delete(l.connections, lc)
+	l.mu.Lock()
+	_, ok := l.connections[lc]
+	if ok {
+		delete(l.connections, lc)
+		l.closed.Add(1)
+		if lc.tenant != "" {
+			l.leaveTenant(lc)
+		}
+	}
+	idle := ok && len(l.connections) == 0 && l.pendingAccepts.Load() == 0
+	l.mu.Unlock()
+
+	if idle && l.opts.onIdle != nil {
+		l.opts.onIdle()
+	}
 }
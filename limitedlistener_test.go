@@ -4,10 +4,16 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/rand"
 	"net"
+	"reflect"
 	"sync"
+	"syscall"
 	"testing"
 	"time"
+
+	"golang.org/x/time/rate"
 )
 
 // TestIfSatisfiesLimitListenerInterface verifies that the LimitedListener type implements the LimitListener interface.
@@ -124,24 +130,72 @@ func TestSetLimits(t *testing.T) {
 			}
 
 			limitedListener.SetLimits(tc.global, tc.perConn)
-			limitedListener.RLock()
-			gotGlobal := int(limitedListener.globalLimiter.Limit())
+			limitedListener.mu.RLock()
+			gotGlobal := int(limitedListener.globalReadLimiter.Limit())
 			gotPerConn := limitedListener.perConnBandwidthLimit
 			if gotGlobal != tc.wantGlobal || gotPerConn != tc.wantPerConn {
 				t.Errorf("expected: global: %d, perConn %d, but got global: %d, perConn %d", tc.wantGlobal, tc.wantPerConn, gotGlobal, gotPerConn)
 			}
 
 			for connection := range limitedListener.connections {
-				if int(connection.limiter.Limit()) != tc.wantPerConn || connection.limiter.Burst() != tc.wantPerConn {
+				limiter := connection.limiter.Load()
+				if limiter == nil {
+					continue
+				}
+				if int(limiter.Limit()) != tc.wantPerConn || limiter.Burst() != tc.wantPerConn {
 					t.Errorf("expected: global: %d, perConn %d, but got global: %d, perConn %d", tc.wantGlobal, tc.wantPerConn, gotGlobal, gotPerConn)
 				}
 			}
 
-			limitedListener.RUnlock()
+			limitedListener.mu.RUnlock()
 		})
 	}
 }
 
+// TestSetLimitsAt verifies that SetLimitsAt counts the tokens accumulated under the old limits up to the given time.
+func TestSetLimitsAt(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	listener, err := NewLimitedListener(nil, 1_000, 100, WithClock(clock))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	server, client := net.Pipe()
+	defer client.Close()
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	global, perConn := listener.globalReadLimiter, lc.perConnLimiter()
+	if !global.AllowN(clock.Now(), 1_000) || !perConn.AllowN(clock.Now(), 100) {
+		t.Fatal("expected the initial bursts to be available")
+	}
+
+	assertTokens := func(step string, wantGlobal, wantPerConn float64) {
+		t.Helper()
+		if got := global.TokensAt(clock.Now()); got != wantGlobal {
+			t.Errorf("%s: expected %v global tokens, got %v", step, wantGlobal, got)
+		}
+		if got := perConn.TokensAt(clock.Now()); got != wantPerConn {
+			t.Errorf("%s: expected %v per-connection tokens, got %v", step, wantPerConn, got)
+		}
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	listener.SetLimitsAt(clock.Now(), 2_000, 400)
+	assertTokens("right after raising the limits", 500, 50)
+
+	clock.Advance(100 * time.Millisecond)
+	assertTokens("100ms after raising the limits", 700, 90)
+
+	listener.SetLimitsAt(clock.Now(), 600, 60)
+	assertTokens("right after lowering the limits", 600, 60)
+
+	listener.SetLimitsAt(clock.Now(), 10, 20)
+	if got := int(global.Limit()); got != 600 {
+		t.Errorf("expected invalid limits to be ignored, got a global limit of %d", got)
+	}
+}
+
 // TestConnectionCleaning tests the behavior of the LimitedListener to ensure that connections are properly registered and cleaned up.
 func TestConnectionCleaning(t *testing.T) {
 
@@ -185,8 +239,8 @@ func TestConnectionCleaning(t *testing.T) {
 
 	time.Sleep(100 * time.Millisecond)
 
-	if len(limitedlistener.connections) != 1 {
-		t.Errorf("expected 1 connection but got %d", len(limitedlistener.connections))
+	if len(limitedlistener.trackedConnections()) != 1 {
+		t.Errorf("expected 1 connection but got %d", len(limitedlistener.trackedConnections()))
 	}
 
 	_, err = conn.Write([]byte("test"))
@@ -198,7 +252,889 @@ func TestConnectionCleaning(t *testing.T) {
 
 	time.Sleep(100 * time.Millisecond)
 
-	if len(limitedlistener.connections) != 0 {
-		t.Errorf("expected 0 connections but got %d", len(limitedlistener.connections))
+	if len(limitedlistener.trackedConnections()) != 0 {
+		t.Errorf("expected 0 connections but got %d", len(limitedlistener.trackedConnections()))
+	}
+}
+
+// TestReadRateWithShortReads verifies that only the bytes actually read are charged to the limiters.
+// The client writes randomly sized segments and the server reads into randomly sized buffers; the
+// sustained rate must still converge to the configured limit within 1%.
+func TestReadRateWithShortReads(t *testing.T) {
+	const limit = 50_000
+	const total = limit + 2*limit // initial burst plus two seconds at the configured rate
+
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer listener.Close()
+
+	limitedListener, err := NewLimitedListener(listener, limit, limit)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	go func() {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Errorf("dial error: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		rng := rand.New(rand.NewSource(1))
+		for sent := 0; sent < total; {
+			size := min(1+rng.Intn(2048), total-sent)
+			if _, err := conn.Write(make([]byte, size)); err != nil {
+				t.Errorf("write error: %v", err)
+				return
+			}
+			sent += size
+		}
+	}()
+
+	conn, err := limitedListener.Accept()
+	if err != nil {
+		t.Fatalf("accept error: %v", err)
+	}
+	defer conn.Close()
+
+	rng := rand.New(rand.NewSource(2))
+	start := time.Now()
+	received := 0
+	for received < total {
+		n, err := conn.Read(make([]byte, 1+rng.Intn(4096)))
+		received += n
+		if err != nil {
+			t.Fatalf("read error: %v", err)
+		}
+	}
+	elapsed := time.Since(start).Seconds()
+
+	actualRate := float64(total-limit) / elapsed
+	if actualRate < limit*0.99 || actualRate > limit*1.01 {
+		t.Errorf("expected rate within 1%% of %d bytes/s, but got %.2f bytes/s", limit, actualRate)
+	}
+}
+
+// TestListen verifies that Listen binds the address, exposes it through Addr and transfers data through the wrapped listener.
+func TestListen(t *testing.T) {
+	limitedListener, err := Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer limitedListener.Close()
+
+	payload := []byte("hello limited listener")
+
+	go func() {
+		conn, err := net.Dial("tcp", limitedListener.Addr().String())
+		if err != nil {
+			t.Errorf("dial error: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		if _, err := conn.Write(payload); err != nil {
+			t.Errorf("write error: %v", err)
+		}
+	}()
+
+	conn, err := limitedListener.Accept()
+	if err != nil {
+		t.Fatalf("accept error: %v", err)
+	}
+	defer conn.Close()
+
+	got, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if string(got) != string(payload) {
+		t.Errorf("expected %q, but got %q", payload, got)
+	}
+}
+
+// TestListenValidationErrors verifies that Listen rejects invalid limits before binding the address.
+func TestListenValidationErrors(t *testing.T) {
+	_, err := Listen("tcp", "127.0.0.1:0", WithLimits(10, 100))
+	if !errors.Is(err, ErrInvalidLimits) {
+		t.Errorf("expected %v, but got %v", ErrInvalidLimits, err)
+	}
+}
+
+// TestListenConfig verifies that Listen creates the socket through the configured net.ListenConfig.
+func TestListenConfig(t *testing.T) {
+	var fd uintptr
+	config := net.ListenConfig{
+		Control: func(network, address string, c syscall.RawConn) error {
+			return c.Control(func(socket uintptr) {
+				fd = socket
+			})
+		},
+	}
+
+	limitedListener, err := Listen("tcp", "127.0.0.1:0", WithListenConfig(config))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer limitedListener.Close()
+
+	if fd == 0 {
+		t.Errorf("expected the control function to run with the socket's file descriptor")
+	}
+}
+
+// TestLazyPerConnLimiter verifies that the per-connection limiter is only allocated on the first Read
+// and that it picks up limits changed while the connection was idle.
+func TestLazyPerConnLimiter(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 100, 50)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	if lc.limiter.Load() != nil {
+		t.Fatalf("expected no limiter before the first read")
+	}
+
+	listener.SetLimits(80, 40)
+	if lc.limiter.Load() != nil {
+		t.Fatalf("expected SetLimits not to allocate a limiter")
+	}
+
+	go client.Write([]byte("test"))
+
+	if _, err := lc.Read(make([]byte, 4)); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+
+	limiter := lc.limiter.Load()
+	if limiter == nil {
+		t.Fatalf("expected a limiter after the first read")
+	}
+	if int(limiter.Limit()) != 40 || limiter.Burst() != 40 {
+		t.Errorf("expected limit and burst of 40, but got %v and %d", limiter.Limit(), limiter.Burst())
+	}
+}
+
+var benchmarkConnection *LimitedConnection
+
+// BenchmarkIdleConnection measures the cost of wrapping a connection that never transfers data.
+func BenchmarkIdleConnection(b *testing.B) {
+	listener, err := NewLimitedListener(nil, 100, 50)
+	if err != nil {
+		b.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchmarkConnection = newLimitedConnection(nil, listener.globalReadLimiter, listener.perConnBandwidthLimit, listener)
+	}
+}
+
+// TestAdoptAndRelease verifies that a live connection can be moved between listeners and that it obeys the limits of its new owner.
+func TestAdoptAndRelease(t *testing.T) {
+	const newLimit = 20_000
+
+	oldListener, err := Listen("tcp", "127.0.0.1:0", WithLimits(1_000_000, 1_000_000))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer oldListener.Close()
+
+	newListener, err := NewLimitedListener(nil, newLimit, newLimit)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	go func() {
+		conn, err := net.Dial("tcp", oldListener.Addr().String())
+		if err != nil {
+			t.Errorf("dial error: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		conn.Write(make([]byte, 1_000+newLimit+newLimit/2))
+	}()
+
+	conn, err := oldListener.Accept()
+	if err != nil {
+		t.Fatalf("accept error: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := io.ReadFull(conn, make([]byte, 1_000)); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+
+	lc := oldListener.Release(conn.(*LimitedConnection))
+	if lc == nil {
+		t.Fatalf("expected the connection to be released")
+	}
+	if oldListener.Release(lc) != nil {
+		t.Errorf("expected a second release to return nil")
+	}
+	newListener.Adopt(lc)
+
+	if len(oldListener.connections) != 0 || len(newListener.connections) != 1 {
+		t.Errorf("expected the connection to move, but got %d and %d connections", len(oldListener.connections), len(newListener.connections))
+	}
+	if lc.globalReadLimiter.Load() != newListener.globalReadLimiter {
+		t.Errorf("expected the connection to use the new global limiter")
+	}
+
+	start := time.Now()
+	if _, err := io.ReadFull(lc, make([]byte, newLimit+newLimit/2)); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 450*time.Millisecond {
+		t.Errorf("expected the transfer to be throttled to %d bytes/s, but it took %v", newLimit, elapsed)
+	}
+
+	lc.Close()
+	if len(newListener.connections) != 0 {
+		t.Errorf("expected the new listener to clean up the connection on close")
+	}
+}
+
+// TestAdoptClosedConnection verifies that a connection closed between Release and Adopt is not tracked by the new
+// listener.
+func TestAdoptClosedConnection(t *testing.T) {
+	oldListener, err := NewLimitedListener(nil, 1_000, 100)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	newListener, err := NewLimitedListener(nil, 1_000, 100)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	server, client := net.Pipe()
+	defer client.Close()
+	lc := oldListener.Track(server)
+
+	oldListener.Release(lc)
+	lc.Close()
+	newListener.Adopt(lc)
+
+	if got := len(newListener.trackedConnections()); got != 0 {
+		t.Errorf("expected the closed connection not to be adopted, got %d connections", got)
+	}
+}
+
+// wouldBlockConn is a net.Conn whose reads always fail as a nonblocking socket without pending data would.
+type wouldBlockConn struct {
+	net.Conn
+}
+
+func (wouldBlockConn) Read([]byte) (int, error) {
+	return 0, syscall.EAGAIN
+}
+
+// TestWouldBlockReadConsumesNoTokens verifies that a would-block read returns the error without charging the limiters.
+func TestWouldBlockReadConsumesNoTokens(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 100, 50)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := newLimitedConnection(wouldBlockConn{}, listener.globalReadLimiter, listener.perConnBandwidthLimit, nil)
+
+	n, err := lc.Read(make([]byte, 50))
+	if n != 0 || !errors.Is(err, syscall.EAGAIN) {
+		t.Fatalf("expected (0, EAGAIN), but got (%d, %v)", n, err)
+	}
+
+	if tokens := listener.globalReadLimiter.Tokens(); tokens < 100 {
+		t.Errorf("expected 100 global tokens, but got %.2f", tokens)
+	}
+	if tokens := lc.perConnLimiter().Tokens(); tokens < 50 {
+		t.Errorf("expected 50 per-connection tokens, but got %.2f", tokens)
+	}
+}
+
+// TestStopAccepting verifies that new connections are rejected after StopAccepting while existing ones keep transferring.
+func TestStopAccepting(t *testing.T) {
+	limitedListener, err := Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer limitedListener.Close()
+
+	client, err := net.Dial("tcp", limitedListener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	defer client.Close()
+
+	conn, err := limitedListener.Accept()
+	if err != nil {
+		t.Fatalf("accept error: %v", err)
+	}
+	defer conn.Close()
+
+	limitedListener.StopAccepting()
+	if limitedListener.IsAccepting() {
+		t.Errorf("expected the listener to stop accepting")
+	}
+
+	rejected, err := net.Dial("tcp", limitedListener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	defer rejected.Close()
+
+	if _, err := limitedListener.Accept(); !errors.Is(err, ErrNotAccepting) {
+		t.Errorf("expected %v, but got %v", ErrNotAccepting, err)
+	}
+	if _, err := rejected.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("expected the rejected connection to be closed, but got %v", err)
+	}
+
+	if _, err := client.Write([]byte("test")); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if _, err := io.ReadFull(conn, make([]byte, 4)); err != nil {
+		t.Errorf("expected the existing connection to keep transferring, but got %v", err)
+	}
+}
+
+// TestExposedLimiters verifies that draining tokens through the exposed limiters delays the next read.
+func TestExposedLimiters(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 2_000, 1_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	if lc.Limiter() != lc.perConnLimiter() {
+		t.Fatalf("expected Limiter to return the per-connection limiter")
+	}
+	if !lc.Limiter().AllowN(time.Now(), 1_000) {
+		t.Fatalf("expected to drain the per-connection limiter")
+	}
+
+	go client.Write(make([]byte, 500))
+
+	start := time.Now()
+	if _, err := io.ReadFull(lc, make([]byte, 500)); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 450*time.Millisecond {
+		t.Errorf("expected the read to wait for the drained tokens, but it took %v", elapsed)
+	}
+}
+
+// TestAddLimiter verifies that limiters attached to a connection compose with the built-in ones and that the tightest one binds.
+func TestAddLimiter(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 10_000, 10_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	lc.AddLimiter(rate.NewLimiter(1_000, 1_000))
+	lc.AddLimiter(rate.NewLimiter(500, 500))
+
+	go client.Write(make([]byte, 750))
+
+	start := time.Now()
+	if _, err := io.ReadFull(lc, make([]byte, 750)); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 450*time.Millisecond {
+		t.Errorf("expected the 500 bytes/s limiter to bind, but the transfer took %v", elapsed)
+	}
+}
+
+// TestZeroLengthRead verifies that reading into an empty buffer neither calls the underlying connection nor touches the limiters.
+func TestZeroLengthRead(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 100, 50)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := newLimitedConnection(wouldBlockConn{}, listener.globalReadLimiter, listener.perConnBandwidthLimit, nil)
+
+	n, err := lc.Read([]byte{})
+	if n != 0 || err != nil {
+		t.Errorf("expected (0, nil), but got (%d, %v)", n, err)
+	}
+	if lc.limiter.Load() != nil {
+		t.Errorf("expected the per-connection limiter not to be created")
+	}
+}
+
+// TestWrapStreamConn verifies that the throttling core works on a non-TCP connection.
+func TestWrapStreamConn(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	globalLimiter := rate.NewLimiter(1_000, 1_000)
+	lc := WrapStreamConn(server, globalLimiter, 2_000)
+	defer lc.Close()
+
+	go client.Write(make([]byte, 1_500))
+
+	start := time.Now()
+	if _, err := io.ReadFull(lc, make([]byte, 1_500)); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 450*time.Millisecond {
+		t.Errorf("expected the shared 1000 bytes/s limiter to bind, but the transfer took %v", elapsed)
+	}
+
+	unlimited := WrapStreamConn(wouldBlockConn{}, nil, 0)
+	if _, err := unlimited.Read(make([]byte, 10)); !errors.Is(err, syscall.EAGAIN) {
+		t.Errorf("expected the underlying error, but got %v", err)
+	}
+}
+
+// TestGlobalWriteLimit verifies that the global write limit throttles egress without slowing down ingress.
+func TestGlobalWriteLimit(t *testing.T) {
+	limitedListener, err := Listen("tcp", "127.0.0.1:0", WithLimits(1_000_000, 1_000_000), WithGlobalWriteLimit(1_000))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer limitedListener.Close()
+
+	client, err := net.Dial("tcp", limitedListener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	defer client.Close()
+
+	conn, err := limitedListener.Accept()
+	if err != nil {
+		t.Fatalf("accept error: %v", err)
+	}
+	defer conn.Close()
+
+	go io.Copy(io.Discard, client)
+
+	writeDone := make(chan time.Duration)
+	go func() {
+		start := time.Now()
+		if _, err := conn.Write(make([]byte, 1_500)); err != nil {
+			t.Errorf("write error: %v", err)
+		}
+		writeDone <- time.Since(start)
+	}()
+
+	go client.Write(make([]byte, 100_000))
+
+	start := time.Now()
+	if _, err := io.ReadFull(conn, make([]byte, 100_000)); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("expected ingress to stay fast, but it took %v", elapsed)
+	}
+
+	if elapsed := <-writeDone; elapsed < 450*time.Millisecond {
+		t.Errorf("expected egress to be throttled to 1000 bytes/s, but it took %v", elapsed)
+	}
+	if got := conn.(*LimitedConnection).BytesWritten(); got != 1_500 {
+		t.Errorf("expected 1500 bytes written, but got %d", got)
+	}
+
+	limitedListener.SetGlobalWriteLimit(2_000)
+	if got := limitedListener.GlobalWriteLimiter().Limit(); got != 2_000 {
+		t.Errorf("expected the write limit to be updated to 2000, but got %v", got)
+	}
+}
+
+// pipeListener is an in-memory net.Listener handing out the server ends of net.Pipe connections.
+// admitConn runs conn through the admission of an accepted connection, with the same checks and options as
+// Accept, and returns the resulting LimitedConnection.
+func admitConn(t testing.TB, listener *LimitedListener, conn net.Conn) *LimitedConnection {
+	t.Helper()
+	lc, err := listener.admit(conn)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	if lc == nil {
+		t.Fatalf("expected the connection to be wrapped, but it was handed out as is")
+	}
+	return lc
+}
+
+type pipeListener struct{}
+
+func (pipeListener) Accept() (net.Conn, error) {
+	server, client := net.Pipe()
+	client.Close()
+	return server, nil
+}
+
+func (pipeListener) Close() error {
+	return nil
+}
+
+func (pipeListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: "pipe", Net: "pipe"}
+}
+
+// BenchmarkAcceptDuringSetLimits measures the cost of accepting and closing a connection while SetLimits is
+// called in a loop on a listener tracking 50k connections.
+func BenchmarkAcceptDuringSetLimits(b *testing.B) {
+	listener, err := NewLimitedListener(pipeListener{}, 1_000_000, 1_000)
+	if err != nil {
+		b.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	for i := 0; i < 50_000; i++ {
+		lc := admitConn(b, listener, nil)
+		lc.perConnLimiter()
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+				listener.SetLimits(1_000_000, 1_000+i%2)
+			}
+		}
+	}()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		conn, err := listener.Accept()
+		if err != nil {
+			b.Fatalf("accept error: %v", err)
+		}
+		conn.Close()
+	}
+	b.StopTimer()
+
+	close(stop)
+	<-done
+}
+
+// TestPinLimit verifies that a pinned connection keeps its per-connection limit when the listener limits are lowered.
+func TestPinLimit(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 10_000, 1_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	pinned := admitConn(t, listener, nil)
+	other := admitConn(t, listener, nil)
+	pinned.PinLimit(true)
+
+	listener.SetLimits(5_000, 100)
+	if got := limitOf(pinned.Limiter()); got != 1_000 {
+		t.Errorf("expected the pinned connection to keep its limit of 1000, got %d", got)
+	}
+	if got := limitOf(other.Limiter()); got != 100 {
+		t.Errorf("expected the other connection to get the new limit of 100, got %d", got)
+	}
+
+	pinned.PinLimit(false)
+	listener.SetLimits(5_000, 200)
+	if got := limitOf(pinned.Limiter()); got != 200 {
+		t.Errorf("expected the unpinned connection to follow the listener limit again, got %d", got)
+	}
+}
+
+// TestSetConnRateUnderLoad recomputes a fair share of the global limit on every connection open and close,
+// as a fair-share policy would, while the connections keep reading. Shrinking bursts must never make a read fail.
+func TestSetConnRateUnderLoad(t *testing.T) {
+	const global = 1_000_000
+
+	listener, err := NewLimitedListener(nil, global, global)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	recompute := func() {
+		connections := listener.trackedConnections()
+		share := global / max(len(connections), 1)
+		for _, connection := range connections {
+			setConnRate(connection, share)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	deadline := time.Now().Add(300 * time.Millisecond)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for time.Now().Before(deadline) {
+				server, client := net.Pipe()
+				lc, err := listener.admit(server)
+				if err != nil {
+					errs <- err
+					client.Close()
+					return
+				}
+				recompute()
+
+				go client.Write(make([]byte, 64<<10))
+				buf := make([]byte, 64<<10)
+				for received := 0; received < 4<<10; {
+					n, err := lc.Read(buf)
+					if err != nil {
+						errs <- err
+						break
+					}
+					received += n
+				}
+
+				client.Close()
+				lc.Close()
+				recompute()
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("didn't expect a read error while the limits changed, got %v", err)
+	}
+}
+
+// TestListenerDoesNotExposeLocking verifies that the listener's mutex is not part of its public API, so callers
+// cannot lock the listener by accident.
+func TestListenerDoesNotExposeLocking(t *testing.T) {
+	listenerType := reflect.TypeOf(&LimitedListener{})
+	for _, name := range []string{"Lock", "Unlock", "RLock", "RUnlock", "TryLock", "TryRLock", "RLocker"} {
+		if _, ok := listenerType.MethodByName(name); ok {
+			t.Errorf("expected *LimitedListener not to expose %s", name)
+		}
+	}
+}
+
+// TestConnectionIDs verifies that Accept hands out unique IDs that increase in accept order.
+func TestConnectionIDs(t *testing.T) {
+	const accepts = 100
+
+	listener, err := NewLimitedListener(pipeListener{}, 1_000, 1_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	for want := uint64(1); want <= accepts; want++ {
+		conn, err := listener.Accept()
+		if err != nil {
+			t.Fatalf("accept error: %v", err)
+		}
+		if got := conn.(*LimitedConnection).ID(); got != want {
+			t.Errorf("expected ID %d, got %d", want, got)
+		}
+	}
+}
+
+// failingCloseConn is a net.Conn whose Close fails and counts its calls.
+type failingCloseConn struct {
+	net.Conn
+	closes int
+}
+
+func (c *failingCloseConn) Close() error {
+	c.closes++
+	return fmt.Errorf("close failed %d", c.closes)
+}
+
+// TestCloseIdempotent verifies that closing a connection twice closes the underlying connection and removes it
+// from the listener only once, returning the same error both times.
+func TestCloseIdempotent(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 1_000, 1_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	conn := &failingCloseConn{}
+	lc := admitConn(t, listener, conn)
+
+	first := lc.Close()
+	second := lc.Close()
+
+	if first == nil || first != second {
+		t.Errorf("expected the same close error twice, got %v and %v", first, second)
+	}
+	if conn.closes != 1 {
+		t.Errorf("expected the underlying connection to be closed once, got %d", conn.closes)
+	}
+	if stats := listener.Stats(); stats.ActiveConnections != 0 || stats.ClosedConnections != 1 {
+		t.Errorf("expected a single removal despite the close error, got %+v", stats)
+	}
+}
+
+// TestAvailableTokens verifies that the reported tokens drop when the connection reads and recover over time.
+func TestAvailableTokens(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 10_000, 1_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	if got := lc.AvailableTokens(); got != 1_000 {
+		t.Errorf("expected a full burst of 1000 tokens, got %v", got)
+	}
+
+	go client.Write(make([]byte, 1_000))
+	if _, err := io.ReadFull(lc, make([]byte, 1_000)); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	drained := lc.AvailableTokens()
+	if drained > 100 {
+		t.Errorf("expected the read to drain the tokens, got %v", drained)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	if got := lc.AvailableTokens(); got < drained+150 {
+		t.Errorf("expected the tokens to recover from %v, got %v", drained, got)
+	}
+
+	unlimited := newLimitedConnection(nil, listener.globalReadLimiter, 0, nil)
+	if got := unlimited.AvailableTokens(); !math.IsInf(got, 1) {
+		t.Errorf("expected +Inf tokens without a per-connection limit, got %v", got)
+	}
+}
+
+// TestConcurrentAcceptAndClose stresses the connections map with concurrent accepts, closes and limit changes.
+// Run with -race: the map must only be mutated under the write lock.
+func TestConcurrentAcceptAndClose(t *testing.T) {
+	const workers, perWorker = 8, 200
+
+	listener, err := NewLimitedListener(pipeListener{}, 1_000_000, 1_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+				listener.SetLimits(1_000_000, 1_000+i%2)
+				listener.trackedConnections()
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWorker; j++ {
+				conn, err := listener.Accept()
+				if err != nil {
+					t.Errorf("accept error: %v", err)
+					return
+				}
+				conn.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	<-done
+
+	if got := len(listener.trackedConnections()); got != 0 {
+		t.Errorf("expected every connection to be removed, %d left", got)
+	}
+	if accepted, closed := listener.accepted.Load(), listener.closed.Load(); accepted != workers*perWorker || closed != accepted {
+		t.Errorf("expected %d accepted and closed connections, got %d and %d", workers*perWorker, accepted, closed)
+	}
+}
+
+// TestLocalListenerAddr verifies that each connection records the address of the listener that accepted it.
+func TestLocalListenerAddr(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		listener, err := Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("didn't expect error but got one: %v", err)
+		}
+		defer listener.Close()
+
+		client, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("didn't expect error but got one: %v", err)
+		}
+		defer client.Close()
+
+		conn, err := listener.Accept()
+		if err != nil {
+			t.Fatalf("didn't expect error but got one: %v", err)
+		}
+		defer conn.Close()
+
+		lc := conn.(*LimitedConnection)
+		if got := lc.LocalListenerAddr(); got != listener.Addr() {
+			t.Errorf("expected the listener address %v, got %v", listener.Addr(), got)
+		}
+		if got := lc.Snapshot().LocalListenerAddr; got != listener.Addr().String() {
+			t.Errorf("expected the snapshot to record %v, got %q", listener.Addr(), got)
+		}
+	}
+
+	if got := WrapStreamConn(nil, nil, 0).LocalListenerAddr(); got != nil {
+		t.Errorf("expected no listener address for a wrapped stream, got %v", got)
+	}
+}
+
+// nilConnListener is a misbehaving net.Listener whose Accept returns neither a connection nor an error.
+type nilConnListener struct {
+	pipeListener
+}
+
+func (nilConnListener) Accept() (net.Conn, error) {
+	return nil, nil
+}
+
+// TestAcceptNilConn verifies that Accept reports a nil connection from the underlying listener as ErrNilConn.
+func TestAcceptNilConn(t *testing.T) {
+	listener, err := NewLimitedListener(nilConnListener{}, 1_000, 100)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	conn, err := listener.Accept()
+	if !errors.Is(err, ErrNilConn) {
+		t.Errorf("expected ErrNilConn, got %v", err)
+	}
+	if conn != nil {
+		t.Errorf("expected no connection, got %v", conn)
+	}
+	if got := listener.Stats().AcceptedConnections; got != 0 {
+		t.Errorf("expected no accepted connection, got %d", got)
 	}
 }
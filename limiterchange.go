@@ -0,0 +1,24 @@
+package limitedlistener
+
+// WithOnLimiterChange calls fn with the new limit whenever the per-connection limit of a connection is changed
+// afterwards: by SetLimits and the other limit changes, including the recomputation of WithPerConnPercent, by
+// WithRateDecay and by Adopt. It is not called for the limit a connection starts with, nor when a change leaves
+// the limit as it was. It runs on the goroutine making the change, outside the listener lock but possibly in the
+// middle of a limit change, so it must not change the limits itself.
+func WithOnLimiterChange(fn func(lc *LimitedConnection, bytesPerSecond int)) Option {
+	return func(o *options) {
+		o.onLimiterChange = fn
+	}
+}
+
+// notifyLimiterChange calls the OnLimiterChange callback with the current per-connection limit of the connection.
+func (lc *LimitedConnection) notifyLimiterChange() {
+	fn := lc.options().onLimiterChange
+	if fn == nil {
+		return
+	}
+	lc.mu.Lock()
+	bytesPerSecond := lc.bytesPerSecond
+	lc.mu.Unlock()
+	fn(lc, bytesPerSecond)
+}
@@ -0,0 +1,40 @@
+package limitedlistener
+
+import (
+	"net"
+	"testing"
+)
+
+// TestOnLimiterChange verifies that the callback fires with the new limit for every connection SetLimits changes.
+func TestOnLimiterChange(t *testing.T) {
+	changes := make(map[*LimitedConnection]int)
+	listener, err := NewLimitedListener(nil, 10_000, 1_000, WithOnLimiterChange(func(lc *LimitedConnection, bytesPerSecond int) {
+		changes[lc] = bytesPerSecond
+	}))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	var conns []*LimitedConnection
+	for i := 0; i < 2; i++ {
+		server, client := net.Pipe()
+		defer client.Close()
+		conns = append(conns, listener.Track(server))
+		defer conns[i].Close()
+	}
+	if len(changes) != 0 {
+		t.Fatalf("expected no change for the limits the connections start with, got %v", changes)
+	}
+
+	listener.SetLimits(10_000, 1_000)
+	if len(changes) != 0 {
+		t.Fatalf("expected no change when the limits stay the same, got %v", changes)
+	}
+
+	listener.SetLimits(10_000, 2_500)
+	for _, lc := range conns {
+		if got, ok := changes[lc]; !ok || got != 2_500 {
+			t.Errorf("expected connection %d to be notified of the new limit of 2500, got %d", lc.ID(), got)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package limitedlistener
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/aubermardegan/limitedlistener/testutil"
+)
+
+// TestReadRateOnSimulatedLink verifies the read rate over an in-memory link: on a link faster than the limit the
+// limit sets the rate, and on a slower one the limiter adds no delay of its own.
+func TestReadRateOnSimulatedLink(t *testing.T) {
+	testCases := []struct {
+		test     string
+		link     testutil.Link
+		size     int
+		min, max time.Duration
+	}{
+		// 40000 bytes at 20000 bytes per second, the first 20000 covered by the burst.
+		{"fast link", testutil.Link{Latency: 5 * time.Millisecond, Bandwidth: 10_000_000}, 40_000, 950 * time.Millisecond, 1_300 * time.Millisecond},
+		// 5000 bytes over a 5000 bytes per second link, well under the limit.
+		{"slow link", testutil.Link{Latency: 5 * time.Millisecond, Bandwidth: 5_000}, 5_000, 950 * time.Millisecond, 1_300 * time.Millisecond},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.test, func(t *testing.T) {
+			link := testutil.NewListener(tc.link)
+			listener, err := NewLimitedListener(link, 100_000, 20_000)
+			if err != nil {
+				t.Fatalf("didn't expect error but got one: %v", err)
+			}
+			defer listener.Close()
+
+			go func() {
+				client, err := link.Dial()
+				if err != nil {
+					return
+				}
+				defer client.Close()
+				for written := 0; written < tc.size; written += 1_000 {
+					if _, err := client.Write(make([]byte, 1_000)); err != nil {
+						return
+					}
+				}
+			}()
+
+			conn, err := listener.Accept()
+			if err != nil {
+				t.Fatalf("didn't expect error but got one: %v", err)
+			}
+			defer conn.Close()
+
+			start := time.Now()
+			if _, err := io.ReadFull(conn, make([]byte, tc.size)); err != nil {
+				t.Fatalf("didn't expect error but got one: %v", err)
+			}
+			if elapsed := time.Since(start); elapsed < tc.min || elapsed > tc.max {
+				t.Errorf("expected reading %d bytes to take between %v and %v, took %v", tc.size, tc.min, tc.max, elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package limitedlistener
+
+// WithMeasureOnly starts the listener in measure-only mode: reads and writes bypass the rate limiters as if
+// no limit were set, while the byte counters, stats, throughput samples and callbacks keep running. It is a safe
+// dry run to observe the actual bandwidth usage before picking limits. Pause and the byte quotas still apply.
+func WithMeasureOnly() Option {
+	return func(o *options) {
+		o.measureOnly = true
+	}
+}
+
+// SetMeasureOnly switches measure-only mode on or off at runtime, see WithMeasureOnly. The limiters keep their
+// configuration while it is on, so turning it off enforces the limits in force before.
+func (l *LimitedListener) SetMeasureOnly(enabled bool) {
+	l.measureOnly.Store(enabled)
+}
+
+// IsMeasureOnly reports whether the listener is in measure-only mode.
+func (l *LimitedListener) IsMeasureOnly() bool {
+	return l.measureOnly.Load()
+}
+
+// measureOnly reports whether the listener owning the connection is in measure-only mode.
+func (lc *LimitedConnection) measureOnly() bool {
+	parent := lc.parentListener.Load()
+	return parent != nil && parent.measureOnly.Load()
+}
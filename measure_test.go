@@ -0,0 +1,61 @@
+package limitedlistener
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestMeasureOnly verifies that measure-only mode transfers at full speed while the byte counters keep accumulating,
+// and that the limits engage again once it is switched off.
+func TestMeasureOnly(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 1_000, 1_000, WithGlobalWriteLimit(1_000), WithMeasureOnly())
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	if !listener.IsMeasureOnly() {
+		t.Fatalf("expected the listener to start in measure-only mode")
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	go client.Write(make([]byte, 100_000))
+	go io.Copy(io.Discard, client)
+
+	start := time.Now()
+	if _, err := io.ReadFull(lc, make([]byte, 100_000)); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if _, err := lc.Write(make([]byte, 50_000)); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("expected a full speed transfer in measure-only mode, but it took %v", elapsed)
+	}
+
+	if got := lc.BytesRead(); got != 100_000 {
+		t.Errorf("expected 100000 bytes read, got %d", got)
+	}
+	if got := lc.BytesWritten(); got != 50_000 {
+		t.Errorf("expected 50000 bytes written, got %d", got)
+	}
+	if got := listener.TotalBytes(); got != 150_000 {
+		t.Errorf("expected 150000 total bytes, got %d", got)
+	}
+
+	listener.SetMeasureOnly(false)
+	go client.Write(make([]byte, 1_500))
+
+	start = time.Now()
+	if _, err := io.ReadFull(lc, make([]byte, 1_500)); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 450*time.Millisecond {
+		t.Errorf("expected reads to be throttled once measure-only mode is off, but it took %v", elapsed)
+	}
+}
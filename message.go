@@ -0,0 +1,37 @@
+package limitedlistener
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrMessageTooLarge = fmt.Errorf("message exceeds the maximum length")
+
+// ReadMessage reads a length-prefixed message: a 4-byte big-endian length followed by that many bytes of payload,
+// both read through the limiters like any other Read. Lengths above maxLen are rejected with ErrMessageTooLarge
+// before the payload is read, leaving the connection in the middle of the frame.
+//
+// It returns io.EOF if the connection ends cleanly before a message, and io.ErrUnexpectedEOF if it ends
+// in the middle of one.
+func (lc *LimitedConnection) ReadMessage(maxLen int) ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(lc, header[:]); err != nil {
+		return nil, err
+	}
+
+	length := binary.BigEndian.Uint32(header[:])
+	if maxLen < 0 || uint64(length) > uint64(maxLen) {
+		return nil, fmt.Errorf("%w: %d bytes, at most %d allowed", ErrMessageTooLarge, length, maxLen)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(lc, payload); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return payload, nil
+}
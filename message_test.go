@@ -0,0 +1,87 @@
+package limitedlistener
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// frame returns payload prefixed with its 4-byte big-endian length.
+func frame(payload []byte) []byte {
+	return append(binary.BigEndian.AppendUint32(nil, uint32(len(payload))), payload...)
+}
+
+// TestReadMessage verifies that framed messages are parsed correctly and read at the per-connection rate.
+func TestReadMessage(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 10_000, 1_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	messages := [][]byte{[]byte("hello"), {}, make([]byte, 1_500)}
+	go func() {
+		for _, message := range messages {
+			client.Write(frame(message))
+		}
+		client.Close()
+	}()
+
+	start := time.Now()
+	for i, want := range messages {
+		got, err := lc.ReadMessage(2_000)
+		if err != nil {
+			t.Fatalf("message %d: read error: %v", i, err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("message %d: expected %d bytes %q, got %d bytes", i, len(want), want[:min(len(want), 10)], len(got))
+		}
+	}
+	// 1517 bytes at 1000 bytes per second after the 1000-byte burst take about half a second.
+	if elapsed := time.Since(start); elapsed < 450*time.Millisecond {
+		t.Errorf("expected the messages to be throttled, but they took %v", elapsed)
+	}
+
+	if _, err := lc.ReadMessage(2_000); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF after the last message, got %v", err)
+	}
+}
+
+// TestReadMessageErrors verifies the errors of oversized and truncated messages.
+func TestReadMessageErrors(t *testing.T) {
+	testCases := []struct {
+		test string
+		data []byte
+		want error
+	}{
+		{"A length above the maximum should be rejected", frame(make([]byte, 11)), ErrMessageTooLarge},
+		{"A truncated header should be an unexpected EOF", []byte{0, 0}, io.ErrUnexpectedEOF},
+		{"A truncated payload should be an unexpected EOF", frame([]byte("hello"))[:6], io.ErrUnexpectedEOF},
+		{"A header without payload should be an unexpected EOF", frame([]byte("hello"))[:4], io.ErrUnexpectedEOF},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.test, func(t *testing.T) {
+			server, client := net.Pipe()
+			lc := WrapStreamConn(server, nil, 0)
+			defer lc.Close()
+
+			go func() {
+				client.Write(tc.data)
+				client.Close()
+			}()
+
+			if _, err := lc.ReadMessage(10); !errors.Is(err, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+}
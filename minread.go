@@ -0,0 +1,25 @@
+package limitedlistener
+
+// WithMinReadSize makes Read return at least n bytes per call, or all of the caller's buffer if it is smaller,
+// unless the connection hits EOF or an error first. At low rates the reads are otherwise clamped to the burst and
+// arrive in small pieces; with a minimum size Read waits for the data and the tokens of a larger read instead,
+// trading latency for fewer calls. A maximum read wait set with WithMaxReadWait takes precedence.
+func WithMinReadSize(n int) Option {
+	return func(o *options) {
+		o.minReadSize = n
+	}
+}
+
+// readAtLeast reads from the underlying connection into b until at least atLeast bytes are read or the read fails.
+// A read returning no data and no error ends the loop too, so a nonblocking connection doesn't make it spin.
+func (lc *LimitedConnection) readAtLeast(b []byte, atLeast int) (int, error) {
+	n := 0
+	for n < atLeast {
+		nn, err := lc.readConn(b[n:])
+		n += nn
+		if err != nil || nn == 0 {
+			return n, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,52 @@
+package limitedlistener
+
+import (
+	"net"
+	"testing"
+)
+
+// TestMinReadSize verifies that at a low rate, with the peer writing small pieces, reads return the minimum size
+// instead of each piece on its own.
+func TestMinReadSize(t *testing.T) {
+	for _, tc := range []struct {
+		test     string
+		opts     []Option
+		min, max int
+	}{
+		{"without minimum", nil, 1, 100},
+		{"with minimum", []Option{WithMinReadSize(5_000)}, 5_000, 5_000},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			server, client := net.Pipe()
+			defer client.Close()
+
+			listener, err := NewLimitedListener(nil, 100_000, 10_000, tc.opts...)
+			if err != nil {
+				t.Fatalf("didn't expect error but got one: %v", err)
+			}
+
+			lc := admitConn(t, listener, server)
+			defer lc.Close()
+
+			go func() {
+				chunk := make([]byte, 100)
+				for {
+					if _, err := client.Write(chunk); err != nil {
+						return
+					}
+				}
+			}()
+
+			buf := make([]byte, 8_192)
+			for i := 0; i < 3; i++ {
+				n, err := lc.Read(buf)
+				if err != nil {
+					t.Fatalf("didn't expect error but got one: %v", err)
+				}
+				if n < tc.min || n > tc.max {
+					t.Errorf("read %d: expected between %d and %d bytes, got %d", i, tc.min, tc.max, n)
+				}
+			}
+		})
+	}
+}
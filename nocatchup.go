@@ -0,0 +1,37 @@
+package limitedlistener
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// WithNoCatchUp makes Resume empty the global and per-connection limiters, so the transfer restarts at the
+// configured rate instead of with a full burst. Use it for links that are sensitive to bursts.
+func WithNoCatchUp() Option {
+	return func(o *options) {
+		o.noCatchUp = true
+	}
+}
+
+// drainTokens empties the limiters of the listener and of its tracked connections.
+func (l *LimitedListener) drainTokens() {
+	now := time.Now()
+	drainLimiter(l.globalReadLimiter, now)
+	drainLimiter(l.globalWriteLimiter, now)
+	for _, connection := range l.trackedConnections() {
+		if limiter := connection.limiter.Load(); limiter != nil {
+			drainLimiter(limiter, now)
+		}
+	}
+}
+
+// drainLimiter consumes the tokens available in limiter at now. Unlimited limiters are left alone.
+func drainLimiter(limiter *rate.Limiter, now time.Time) {
+	if limiter.Limit() == rate.Inf {
+		return
+	}
+	if tokens := int(limiter.TokensAt(now)); tokens > 0 {
+		limiter.AllowN(now, tokens)
+	}
+}
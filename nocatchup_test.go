@@ -0,0 +1,71 @@
+package limitedlistener
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestNoCatchUp verifies that the transfer resumes at the configured rate after a pause, without a burst.
+func TestNoCatchUp(t *testing.T) {
+	for _, tc := range []struct {
+		test    string
+		opts    []Option
+		spiking bool
+	}{
+		{"catch up", nil, true},
+		{"no catch up", []Option{WithNoCatchUp()}, false},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			server, client := net.Pipe()
+			defer client.Close()
+
+			listener, err := NewLimitedListener(nil, 1_000, 1_000, tc.opts...)
+			if err != nil {
+				t.Fatalf("didn't expect error but got one: %v", err)
+			}
+
+			lc := admitConn(t, listener, server)
+			defer lc.Close()
+
+			go func() {
+				chunk := make([]byte, 100)
+				for {
+					if _, err := client.Write(chunk); err != nil {
+						return
+					}
+				}
+			}()
+
+			var received atomic.Int64
+			go func() {
+				buf := make([]byte, 100)
+				for {
+					n, err := lc.Read(buf)
+					received.Add(int64(n))
+					if err != nil {
+						return
+					}
+				}
+			}()
+
+			listener.Pause()
+			// Let the limiters refill to their full burst while paused.
+			time.Sleep(1200 * time.Millisecond)
+
+			before := received.Load()
+			listener.Resume()
+			time.Sleep(500 * time.Millisecond)
+			got := received.Load() - before
+
+			// At 1000 bytes per second, 500ms allow about 500 bytes; a full burst would add 1000 more.
+			if tc.spiking && got < 1_000 {
+				t.Errorf("expected a catch-up burst after the pause, got %d bytes in 500ms", got)
+			}
+			if !tc.spiking && got > 750 {
+				t.Errorf("expected no more than the configured rate after the pause, got %d bytes in 500ms", got)
+			}
+		})
+	}
+}
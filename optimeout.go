@@ -0,0 +1,70 @@
+package limitedlistener
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// WithOperationTimeout bounds every Read, ReadContext and Write call, limiter waits included, to d. A call not
+// done within d fails with os.ErrDeadlineExceeded, a net.Error whose Timeout method reports true; the bytes
+// transferred before are returned along with it. Unlike an idle timeout it also catches transfers stalled by
+// throttling or by a peer trickling bytes too slowly, while each call that completes in time resets the clock.
+// A deadline set on the connection that expires earlier still applies.
+func WithOperationTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.operationTimeout = d
+	}
+}
+
+// timedRead implements Read and ReadContext, applying the operation timeout if one is set and reporting a close by
+// the first byte timer as ErrNoFirstByte.
+func (lc *LimitedConnection) timedRead(ctx context.Context, b []byte) (int, error) {
+	timeout := lc.options().operationTimeout
+	if timeout <= 0 {
+		n, err := lc.read(ctx, b)
+		return n, lc.firstByteError(err)
+	}
+	ctx, finish := lc.startOperation(ctx, timeout, &lc.readDeadline, lc.Conn.SetReadDeadline)
+	n, err := lc.read(ctx, b)
+	return n, lc.firstByteError(finish(err))
+}
+
+// startOperation starts an operation bounded by timeout. It returns a context cancelled when the timeout passes,
+// for the limiter waits, and moves the underlying deadline set with setDeadline forward to the timeout if the
+// deadline stored in deadline is later. The returned function ends the operation: it restores the underlying
+// deadline and turns the error of an operation cut off by the timeout into os.ErrDeadlineExceeded.
+//
+// The limiter waits get a cancellation rather than a context deadline, as a limiter fails a wait right away
+// when it knows the tokens won't be there before the deadline, even if the operation could finish in time.
+func (lc *LimitedConnection) startOperation(ctx context.Context, timeout time.Duration, deadline *atomic.Int64, setDeadline func(time.Time) error) (context.Context, func(error) error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	timer := time.AfterFunc(timeout, func() { cancel(os.ErrDeadlineExceeded) })
+
+	opDeadline := time.Now().Add(timeout)
+	userDeadline := deadline.Load()
+	shortened := userDeadline == 0 || opDeadline.UnixNano() < userDeadline
+	if shortened {
+		setDeadline(opDeadline)
+	}
+
+	return ctx, func(err error) error {
+		timer.Stop()
+		timedOut := errors.Is(context.Cause(ctx), os.ErrDeadlineExceeded)
+		cancel(nil)
+		if shortened {
+			// The deadline may have been changed with SetReadDeadline or SetWriteDeadline during the operation.
+			if current := deadline.Load(); current != 0 {
+				setDeadline(time.Unix(0, current))
+			} else {
+				setDeadline(time.Time{})
+			}
+		}
+		if err != nil && timedOut {
+			return os.ErrDeadlineExceeded
+		}
+		return err
+	}
+}
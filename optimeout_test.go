@@ -0,0 +1,99 @@
+package limitedlistener
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestOperationTimeoutStalled verifies that a read the peer never answers fails with a timeout.
+func TestOperationTimeoutStalled(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 100_000, 10_000, WithOperationTimeout(200*time.Millisecond))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	start := time.Now()
+	_, err = lc.Read(make([]byte, 100))
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond || elapsed > time.Second {
+		t.Errorf("expected the read to time out after about 200ms, but it took %v", elapsed)
+	}
+}
+
+// TestOperationTimeoutThrottledWrite verifies that a write held back by the limiter longer than the timeout fails
+// with a timeout.
+func TestOperationTimeoutThrottledWrite(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			if _, err := client.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+
+	listener, err := NewLimitedListener(nil, 100_000, 10_000, WithGlobalWriteLimit(1_000), WithOperationTimeout(200*time.Millisecond))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	n, err := lc.Write(make([]byte, 5_000))
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected os.ErrDeadlineExceeded, got %v", err)
+	}
+	if n >= 5_000 {
+		t.Errorf("expected a partial write, got %d bytes", n)
+	}
+}
+
+// TestOperationTimeoutProgressing verifies that a slow peer whose every read completes within the timeout keeps
+// the connection going, and that the underlying deadline is cleared between reads.
+func TestOperationTimeoutProgressing(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 100_000, 10_000, WithOperationTimeout(200*time.Millisecond))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	go func() {
+		for i := 0; i < 8; i++ {
+			time.Sleep(75 * time.Millisecond)
+			if _, err := client.Write([]byte{byte(i)}); err != nil {
+				return
+			}
+		}
+	}()
+
+	buf := make([]byte, 10)
+	for i := 0; i < 8; i++ {
+		n, err := lc.Read(buf)
+		if err != nil {
+			t.Fatalf("read %d: didn't expect error but got one: %v", i, err)
+		}
+		if n != 1 || buf[0] != byte(i) {
+			t.Fatalf("read %d: expected byte %d, got %v", i, i, buf[:n])
+		}
+	}
+}
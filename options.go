@@ -0,0 +1,150 @@
+package limitedlistener
+
+import (
+	"context"
+	"math"
+	"net"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Option configures optional behaviour of a LimitedListener.
+type Option func(*options)
+
+// options holds the configuration assembled from the Option values passed to a constructor.
+type options struct {
+	globalLimit         int
+	perConnLimit        int
+	perConnFraction     float64
+	clampPerConn        bool
+	operationTimeout    time.Duration
+	globalWriteLimit    int
+	globalLimiter       *rate.Limiter
+	totalByteQuota      int64
+	perConnByteQuota    int64
+	intervalBudget      int64
+	budgetInterval      time.Duration
+	closeOnQuota        bool
+	maxReadWait         time.Duration
+	firstByteTimeout    time.Duration
+	readOverhead        int
+	minReadSize         int
+	tokenCost           func(int) int
+	waitErrorPolicy     WaitErrorPolicy
+	readGuard           bool
+	serializeReads      bool
+	measureOnly         bool
+	autoBurst           bool
+	noCatchUp           bool
+	strictDownshift     bool
+	sharedGlobalLimit   bool
+	maxBlockedWaiters   int
+	waitObserver        func(context.Context, WaitEvent)
+	readObserver        func(*LimitedConnection, []byte)
+	maxConcurrentReads  int
+	acceptRate          float64
+	acceptBurst         int
+	maxConnections      int
+	acceptFilter        func(net.Conn) bool
+	rejectResponder     func(net.Conn)
+	admissionController func() bool
+	tenantOf            func(net.Conn) string
+	limitPredicate      func(net.Conn) bool
+	listenConfig        *net.ListenConfig
+
+	workConservingCeiling int
+	warmupBytes           int64
+	warmupDuration        time.Duration
+	samples               int
+	roundRobinQuantum     int
+	sampleInterval        time.Duration
+
+	rateBoundThreshold float64
+	rateBoundWindow    time.Duration
+	onRateBound        func(*LimitedConnection)
+	onIdle             func()
+	onInfeasible       func(float64)
+	onLimiterChange    func(*LimitedConnection, int)
+
+	clock            Clock
+	schedule         []ScheduledLimit
+	controllerTarget int
+	controllerWindow time.Duration
+
+	compactionInterval time.Duration
+	rateDecay          func(int64, time.Duration) int
+
+	bypassWrapperWhenUnlimited bool
+	untracked                  bool
+
+	priorityLength int
+	priorityLimit  func([]byte) int
+}
+
+// defaultOptions is used by connections that are not owned by a listener.
+var defaultOptions = newOptions(nil)
+
+// newOptions applies opts over the default configuration. By default the listener is unlimited.
+func newOptions(opts []Option) options {
+	o := options{clock: systemClock{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// validate checks the limits set through the options. Unset limits are valid and mean unlimited.
+func (o *options) validate() error {
+	if o.perConnFraction < 0 || o.perConnFraction > 1 || math.IsNaN(o.perConnFraction) {
+		return ErrLimitOutOfRange
+	}
+	if o.globalLimit != 0 || o.perConnLimit != 0 {
+		if err := validateLimits(o.globalLimit, perConnFor(o, o.globalLimit, o.perConnLimit)); err != nil {
+			return err
+		}
+	}
+	for _, entry := range o.schedule {
+		if err := validateLimits(entry.Global, entry.PerConn); err != nil {
+			return err
+		}
+		if entry.Start < 0 || entry.Start >= day || entry.End < 0 || entry.End >= day {
+			return ErrScheduleOutOfRange
+		}
+	}
+	if o.globalWriteLimit < 0 || o.workConservingCeiling < 0 || o.maxConnections < 0 || o.readOverhead < 0 || o.roundRobinQuantum < 0 || o.perConnByteQuota < 0 || o.controllerTarget < 0 || o.maxConcurrentReads < 0 || o.maxBlockedWaiters < 0 || o.operationTimeout < 0 || o.minReadSize < 0 || o.firstByteTimeout < 0 || o.intervalBudget < 0 || o.budgetInterval < 0 {
+		return ErrLimitOutOfRange
+	}
+	if o.priorityLength < 0 || (o.priorityLength > 0 && o.priorityLimit == nil) {
+		return ErrLimitOutOfRange
+	}
+	if (o.samples != 0 || o.sampleInterval != 0) && (o.samples <= 0 || o.sampleInterval <= 0) {
+		return ErrLimitOutOfRange
+	}
+	return nil
+}
+
+// WithLimits sets the global and per-connection bandwidth limits in bytes per second.
+// Without it, connections accepted by a listener created with Listen are not throttled.
+func WithLimits(global, perConn int) Option {
+	return func(o *options) {
+		o.globalLimit = global
+		o.perConnLimit = perConn
+	}
+}
+
+// WithGlobalWriteLimit sets a global write bandwidth limit in bytes per second, shared by all connections and
+// independent of the read limits. Without it writes are not throttled.
+func WithGlobalWriteLimit(bytesPerSecond int) Option {
+	return func(o *options) {
+		o.globalWriteLimit = bytesPerSecond
+	}
+}
+
+// WithListenConfig makes Listen create the socket with config, so its Control function can set socket options
+// such as SO_REUSEADDR before the listener is wrapped. Other constructors ignore it.
+func WithListenConfig(config net.ListenConfig) Option {
+	return func(o *options) {
+		o.listenConfig = &config
+	}
+}
@@ -0,0 +1,14 @@
+module github.com/aubermardegan/limitedlistener/otellimitedlistener
+
+go 1.22.0
+
+require (
+	github.com/aubermardegan/limitedlistener v0.0.0-20261017025329-aa6c4a33781d
+	go.opentelemetry.io/otel v1.31.0
+	go.opentelemetry.io/otel/trace v1.31.0
+)
+
+require (
+	golang.org/x/sync v0.8.0 // indirect
+	golang.org/x/time v0.10.0 // indirect
+)
@@ -0,0 +1,31 @@
+// Package otellimitedlistener records the throttling of limitedlistener connections as OpenTelemetry spans.
+// It lives in its own package so that the limitedlistener package itself does not depend on OpenTelemetry.
+package otellimitedlistener
+
+import (
+	"context"
+	"time"
+
+	"github.com/aubermardegan/limitedlistener"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracer creates a span with tracer for every Read and Write that blocked on the limiters, named
+// "limitedlistener.read" or "limitedlistener.write" and covering the wait. Spans carry the connection ID, the
+// number of bytes and the wait duration as attributes, and are children of the span in the context of ReadContext;
+// plain reads and writes start new traces. Transfers that did not block create no span.
+func WithTracer(tracer trace.Tracer) limitedlistener.Option {
+	return limitedlistener.WithWaitObserver(func(ctx context.Context, event limitedlistener.WaitEvent) {
+		end := time.Now()
+		_, span := tracer.Start(ctx, "limitedlistener."+event.Op,
+			trace.WithTimestamp(end.Add(-event.Wait)),
+			trace.WithAttributes(
+				attribute.Int64("limitedlistener.conn.id", int64(event.Conn.ID())),
+				attribute.Int("limitedlistener.bytes", event.Bytes),
+				attribute.Int64("limitedlistener.wait_ns", event.Wait.Nanoseconds()),
+			),
+		)
+		span.End(trace.WithTimestamp(end))
+	})
+}
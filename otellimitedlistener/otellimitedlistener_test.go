@@ -0,0 +1,111 @@
+package otellimitedlistener
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aubermardegan/limitedlistener"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// recordedSpan is a span started through recordingTracer.
+type recordedSpan struct {
+	name       string
+	start      time.Time
+	attributes map[attribute.Key]attribute.Value
+}
+
+// recordingTracer is a trace.Tracer recording the spans it starts.
+type recordingTracer struct {
+	noop.Tracer
+
+	mu    sync.Mutex
+	spans []recordedSpan
+}
+
+func (r *recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	config := trace.NewSpanStartConfig(opts...)
+	span := recordedSpan{name: name, start: config.Timestamp(), attributes: make(map[attribute.Key]attribute.Value)}
+	for _, kv := range config.Attributes() {
+		span.attributes[kv.Key] = kv.Value
+	}
+
+	r.mu.Lock()
+	r.spans = append(r.spans, span)
+	r.mu.Unlock()
+	return r.Tracer.Start(ctx, name, opts...)
+}
+
+func (r *recordingTracer) recorded() []recordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]recordedSpan(nil), r.spans...)
+}
+
+// TestWithTracer verifies that a span is emitted for a throttled read and none for a read that did not block.
+func TestWithTracer(t *testing.T) {
+	tracer := &recordingTracer{}
+	listener, err := limitedlistener.Listen("tcp", "127.0.0.1:0", limitedlistener.WithLimits(1_000, 1_000), WithTracer(tracer))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer listener.Close()
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	defer client.Close()
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("accept error: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := client.Write(make([]byte, 1_000)); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if _, err := io.ReadFull(conn, make([]byte, 1_000)); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if spans := tracer.recorded(); len(spans) != 0 {
+		t.Fatalf("expected no span for a read within the burst, got %d", len(spans))
+	}
+
+	if _, err := client.Write(make([]byte, 500)); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	start := time.Now()
+	if _, err := io.ReadFull(conn, make([]byte, 500)); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+
+	spans := tracer.recorded()
+	if len(spans) == 0 {
+		t.Fatalf("expected a span for the throttled read")
+	}
+	var bytes, wait int64
+	for _, span := range spans {
+		if span.name != "limitedlistener.read" {
+			t.Errorf("expected the span to be named limitedlistener.read, got %q", span.name)
+		}
+		if span.start.Before(start) {
+			t.Errorf("expected the span to start with the wait, got a start %v before the read", start.Sub(span.start))
+		}
+		bytes += span.attributes["limitedlistener.bytes"].AsInt64()
+		wait += span.attributes["limitedlistener.wait_ns"].AsInt64()
+	}
+	if bytes != 500 {
+		t.Errorf("expected the spans to cover 500 bytes, got %d", bytes)
+	}
+	if time.Duration(wait) < 400*time.Millisecond {
+		t.Errorf("expected the spans to cover about 500ms of waiting, got %v", time.Duration(wait))
+	}
+}
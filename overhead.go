@@ -0,0 +1,14 @@
+package limitedlistener
+
+// WithReadOverhead charges every Read bytes tokens on top of the bytes it returns, modeling a fixed per-read
+// framing cost such as TCP/IP headers so the limits apply to the on-the-wire rate rather than the payload rate.
+// The counters and the byte quota still only account the payload.
+//
+// Reads are sized so that the payload plus the overhead fits in the burst of every limiter. If the overhead
+// alone is larger than a burst, reads are shrunk to a single byte and the charge is split over several waits.
+// A negative overhead is rejected with ErrLimitOutOfRange.
+func WithReadOverhead(bytes int) Option {
+	return func(o *options) {
+		o.readOverhead = bytes
+	}
+}
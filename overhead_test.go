@@ -0,0 +1,71 @@
+package limitedlistener
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// TestReadOverhead verifies that the per-read overhead lowers the achieved payload rate accordingly.
+func TestReadOverhead(t *testing.T) {
+	measure := func(opts ...Option) time.Duration {
+		server, client := net.Pipe()
+		defer client.Close()
+
+		listener, err := NewLimitedListener(nil, 20_000, 20_000, opts...)
+		if err != nil {
+			t.Fatalf("didn't expect error but got one: %v", err)
+		}
+
+		lc := admitConn(t, listener, server)
+		defer lc.Close()
+
+		now := time.Now()
+		listener.globalReadLimiter.ReserveN(now, 20_000)
+		lc.Limiter().ReserveN(now, 20_000)
+
+		go client.Write(make([]byte, 10_000))
+
+		start := time.Now()
+		buf := make([]byte, 100)
+		for received := 0; received < 10_000; {
+			n, err := lc.Read(buf)
+			if err != nil {
+				t.Fatalf("read error: %v", err)
+			}
+			received += n
+		}
+		return time.Since(start)
+	}
+
+	plain := measure()
+	withOverhead := measure(WithReadOverhead(100))
+
+	if plain < 400*time.Millisecond || plain > 700*time.Millisecond {
+		t.Errorf("expected 10k bytes at 20k/s to take about 500ms, took %v", plain)
+	}
+	if withOverhead < 900*time.Millisecond || withOverhead > 1300*time.Millisecond {
+		t.Errorf("expected 100 bytes of overhead per 100-byte read to halve the payload rate, took %v", withOverhead)
+	}
+}
+
+// TestReadOverheadOverBurst verifies that an overhead larger than the burst still lets reads progress.
+func TestReadOverheadOverBurst(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 1_000, 1_000, WithReadOverhead(1_500))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	go client.Write(make([]byte, 100))
+
+	n, err := lc.Read(make([]byte, 100))
+	if n != 1 || err != nil {
+		t.Errorf("expected a single byte to be read without error, got (%d, %v)", n, err)
+	}
+}
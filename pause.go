@@ -0,0 +1,119 @@
+package limitedlistener
+
+import (
+	"context"
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// Pause halts the data transfer of every connection of the listener, for example during a maintenance window.
+// New connections are still accepted. Reads and writes block until Resume is called, the connection's deadline
+// passes, the connection is closed or the listener's context is cancelled.
+//
+// The limiters are left untouched while paused, so Resume restores exactly the limits in force before the pause.
+// Blocking on a gate rather than lowering the limiter rate also wakes every waiter as soon as the listener is
+// resumed, instead of leaving them asleep for the delay computed at the paused rate.
+func (l *LimitedListener) Pause() {
+	l.pauseMu.Lock()
+	defer l.pauseMu.Unlock()
+
+	if l.resumed == nil {
+		l.resumed = make(chan struct{})
+	}
+}
+
+// Resume lets the connections transfer data again after Pause. It does nothing if the listener is not paused.
+// With WithNoCatchUp the limiters are emptied first.
+func (l *LimitedListener) Resume() {
+	l.pauseMu.Lock()
+	defer l.pauseMu.Unlock()
+
+	if l.resumed != nil {
+		if l.opts.noCatchUp {
+			l.drainTokens()
+		}
+		close(l.resumed)
+		l.resumed = nil
+	}
+}
+
+// IsPaused reports whether the data transfer of the listener is paused.
+func (l *LimitedListener) IsPaused() bool {
+	return l.pauseGate() != nil
+}
+
+// pauseGate returns a channel closed when the listener is resumed, or nil if it is not paused.
+func (l *LimitedListener) pauseGate() <-chan struct{} {
+	l.pauseMu.Lock()
+	defer l.pauseMu.Unlock()
+
+	return l.resumed
+}
+
+// paused reports whether the listener owning the connection is paused.
+func (lc *LimitedConnection) paused() bool {
+	parent := lc.parentListener.Load()
+	return parent != nil && parent.IsPaused()
+}
+
+// waitResumed blocks while the listener owning the connection is paused. It returns os.ErrDeadlineExceeded
+// once the deadline stored in deadline passes, net.ErrClosed if the connection is closed, or the context error.
+func (lc *LimitedConnection) waitResumed(ctx context.Context, deadline *atomic.Int64) error {
+	parent := lc.parentListener.Load()
+	if parent == nil {
+		return nil
+	}
+	gate := parent.pauseGate()
+	if gate == nil {
+		return nil
+	}
+
+	var timeout <-chan time.Time
+	if d := deadline.Load(); d != 0 {
+		timer := time.NewTimer(time.Until(time.Unix(0, d)))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-gate:
+		return nil
+	case <-lc.closing:
+		return net.ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timeout:
+		return os.ErrDeadlineExceeded
+	}
+}
+
+// SetDeadline sets the read and write deadlines of the underlying connection. The deadlines also bound the
+// time a read or write stays blocked on a paused listener.
+func (lc *LimitedConnection) SetDeadline(t time.Time) error {
+	storeDeadline(&lc.readDeadline, t)
+	storeDeadline(&lc.writeDeadline, t)
+	return lc.Conn.SetDeadline(t)
+}
+
+// SetReadDeadline sets the read deadline of the underlying connection, see SetDeadline.
+func (lc *LimitedConnection) SetReadDeadline(t time.Time) error {
+	storeDeadline(&lc.readDeadline, t)
+	return lc.Conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the write deadline of the underlying connection, see SetDeadline.
+func (lc *LimitedConnection) SetWriteDeadline(t time.Time) error {
+	storeDeadline(&lc.writeDeadline, t)
+	return lc.Conn.SetWriteDeadline(t)
+}
+
+// storeDeadline stores t in dst as Unix nanoseconds, with zero meaning no deadline.
+func storeDeadline(dst *atomic.Int64, t time.Time) {
+	if t.IsZero() {
+		dst.Store(0)
+		return
+	}
+	dst.Store(t.UnixNano())
+}
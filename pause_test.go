@@ -0,0 +1,141 @@
+package limitedlistener
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestPauseResume verifies that no data is transferred while the listener is paused, that the transfer resumes
+// afterwards and that the limits are the same as before the pause.
+func TestPauseResume(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 1_000_000, 1_000_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	go func() {
+		chunk := make([]byte, 1_000)
+		for {
+			if _, err := client.Write(chunk); err != nil {
+				return
+			}
+		}
+	}()
+
+	var received atomic.Int64
+	go func() {
+		buf := make([]byte, 1_000)
+		for {
+			n, err := lc.Read(buf)
+			received.Add(int64(n))
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	before := listener.ExportConfig()
+
+	listener.Pause()
+	if !listener.IsPaused() {
+		t.Fatalf("expected the listener to be paused")
+	}
+	time.Sleep(20 * time.Millisecond)
+	halted := received.Load()
+	time.Sleep(200 * time.Millisecond)
+	if got := received.Load(); got != halted {
+		t.Errorf("expected no data while paused, but %d bytes were received", got-halted)
+	}
+
+	listener.Resume()
+	time.Sleep(100 * time.Millisecond)
+	if got := received.Load(); got == halted {
+		t.Errorf("expected the transfer to resume")
+	}
+	if after := listener.ExportConfig(); after != before {
+		t.Errorf("expected the limits to be restored to %+v, got %+v", before, after)
+	}
+}
+
+// TestPauseDeadline verifies that a read blocked on a paused listener returns once the read deadline passes.
+func TestPauseDeadline(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 1_000_000, 1_000_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	listener.Pause()
+	defer listener.Resume()
+
+	go client.Write(make([]byte, 100))
+
+	if err := lc.SetReadDeadline(time.Now().Add(100 * time.Millisecond)); err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	start := time.Now()
+	_, err = lc.Read(make([]byte, 100))
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("expected os.ErrDeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the read to return at the deadline, but it took %v", elapsed)
+	}
+}
+
+// TestPauseHaltsUnderlyingReads verifies that a read on a paused listener doesn't consume data from the underlying
+// connection until the listener is resumed.
+func TestPauseHaltsUnderlyingReads(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"limited":      nil,
+		"warmup":       {WithWarmup(0, time.Minute)},
+		"measure only": {WithMeasureOnly()},
+	} {
+		server, client := net.Pipe()
+		listener, err := NewLimitedListener(nil, 1_000_000, 1_000_000, opts...)
+		if err != nil {
+			t.Fatalf("%s: didn't expect error but got one: %v", name, err)
+		}
+		lc := listener.Track(server)
+		listener.Pause()
+
+		go lc.Read(make([]byte, 10))
+		written := make(chan struct{})
+		go func() {
+			client.Write([]byte("x"))
+			close(written)
+		}()
+
+		select {
+		case <-written:
+			t.Errorf("%s: expected no data to be read from the connection while paused", name)
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		listener.Resume()
+		select {
+		case <-written:
+		case <-time.After(time.Second):
+			t.Errorf("%s: expected the read to go through once resumed", name)
+		}
+		lc.Close()
+		client.Close()
+	}
+}
@@ -0,0 +1,12 @@
+package limitedlistener
+
+// WithPerConnPercent derives the per-connection limit from the global limit: every connection gets fraction times
+// the global limit, for example 0.1 for 10%, rounded to the nearest integer with a floor of 1 byte per second.
+// The per-connection limit is recomputed whenever the global limit changes through SetLimits, SetLimitsAt,
+// ScaleLimits or a schedule, and the per-connection limits passed to them are ignored. fraction must be in (0, 1],
+// so the derived limit never exceeds the global one. An unlimited global limit leaves the per-connection limit as is.
+func WithPerConnPercent(fraction float64) Option {
+	return func(o *options) {
+		o.perConnFraction = fraction
+	}
+}
@@ -0,0 +1,53 @@
+package limitedlistener
+
+import (
+	"errors"
+	"math"
+	"net"
+	"testing"
+)
+
+// TestPerConnPercent verifies that the per-connection limits follow the global limit when it changes.
+func TestPerConnPercent(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 10_000, 10_000, WithPerConnPercent(0.25))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	server, client := net.Pipe()
+	defer client.Close()
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	assertLimits := func(step string, wantGlobal, wantPerConn int) {
+		t.Helper()
+		if got := listener.Stats(); got.GlobalLimit != wantGlobal || got.PerConnLimit != wantPerConn {
+			t.Errorf("%s: expected global %d, perConn %d, got global %d, perConn %d", step, wantGlobal, wantPerConn, got.GlobalLimit, got.PerConnLimit)
+		}
+		if got := int(lc.Limiter().Limit()); got != wantPerConn {
+			t.Errorf("%s: expected the connection limit to be %d, got %d", step, wantPerConn, got)
+		}
+	}
+
+	assertLimits("after creation", 10_000, 2_500)
+
+	listener.SetLimits(40_000, 1)
+	assertLimits("after raising the global limit", 40_000, 10_000)
+
+	if err := listener.ScaleLimits(0.5); err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	assertLimits("after halving the limits", 20_000, 5_000)
+
+	listener.SetLimits(3, 0)
+	assertLimits("after lowering the global limit to a few bytes", 3, 1)
+}
+
+// TestPerConnPercentValidation verifies that fractions outside (0, 1] are rejected.
+func TestPerConnPercentValidation(t *testing.T) {
+	for _, fraction := range []float64{-0.1, 1.5, math.NaN()} {
+		if _, err := Listen("tcp", "127.0.0.1:0", WithLimits(1_000, 100), WithPerConnPercent(fraction)); !errors.Is(err, ErrLimitOutOfRange) {
+			t.Errorf("expected ErrLimitOutOfRange for a fraction of %v, got %v", fraction, err)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package limitedlistener
+
+import (
+	"golang.org/x/time/rate"
+)
+
+// Pool is a shared bandwidth budget for an explicit set of connections, for example the connections of one
+// HTTP client pool. The pool as a whole is capped while its connections share the budget freely.
+type Pool struct {
+	limiter *rate.Limiter
+}
+
+// NewPool creates a pool limited to bytesPerSecond in aggregate, where zero means unlimited. The pool is
+// independent of any listener, whose limits still apply to every attached connection.
+func NewPool(bytesPerSecond int) *Pool {
+	return &Pool{limiter: newLimiter(max(bytesPerSecond, 0))}
+}
+
+// Attach makes reads of lc additionally wait on the pool's limiter. Attaching a connection twice has no effect.
+func (p *Pool) Attach(lc *LimitedConnection) {
+	lc.AddLimiter(p.limiter)
+}
+
+// Detach removes lc from the pool; its reads no longer wait on the pool's limiter.
+func (p *Pool) Detach(lc *LimitedConnection) {
+	lc.removeLimiter(p.limiter)
+}
+
+// SetLimit updates the aggregate limit of the pool, where zero means unlimited.
+func (p *Pool) SetLimit(bytesPerSecond int) {
+	setLimiterRate(p.limiter, bytesPerSecond)
+}
+
+// Limiter returns the pool's limiter for advanced tuning.
+func (p *Pool) Limiter() *rate.Limiter {
+	return p.limiter
+}
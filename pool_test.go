@@ -0,0 +1,67 @@
+package limitedlistener
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestPool verifies that two connections attached to a pool are bounded by the pool's aggregate limit.
+func TestPool(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 1_000_000, 1_000_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	pool := NewPool(2_000)
+	pool.Limiter().ReserveN(time.Now(), 2_000)
+
+	var received atomic.Int64
+	for i := 0; i < 2; i++ {
+		server, client := net.Pipe()
+		defer client.Close()
+
+		lc := admitConn(t, listener, server)
+		defer lc.Close()
+		pool.Attach(lc)
+		pool.Attach(lc)
+
+		go client.Write(make([]byte, 10_000))
+		go func() {
+			buf := make([]byte, 100)
+			for {
+				n, err := lc.Read(buf)
+				received.Add(int64(n))
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+
+	time.Sleep(time.Second)
+
+	if got := received.Load(); got < 1_500 || got > 2_500 {
+		t.Errorf("expected the pool to cap both connections at about 2000 bytes in total, got %d", got)
+	}
+}
+
+// TestPoolDetach verifies that a detached connection no longer waits on the pool.
+func TestPoolDetach(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 1_000_000, 1_000_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	pool := NewPool(100)
+
+	lc := admitConn(t, listener, nil)
+	pool.Attach(lc)
+	if got := len(lc.limiters(nil)); got != 3 {
+		t.Fatalf("expected the pool limiter in the chain, got %d limiters", got)
+	}
+
+	pool.Detach(lc)
+	if got := len(lc.limiters(nil)); got != 2 {
+		t.Errorf("expected the pool limiter to be removed from the chain, got %d limiters", got)
+	}
+}
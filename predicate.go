@@ -0,0 +1,22 @@
+package limitedlistener
+
+import "net"
+
+// WithLimitPredicate applies the bandwidth limits only to the connections for which fn returns true, for example
+// based on the remote address, the local port or the time of day. The other connections are still tracked and
+// counted, but read and write through unlimited limiters and are left alone by SetLimits and Adopt. Limiters
+// attached with AddLimiter or through a tenant still apply. fn is called once per connection, in Accept.
+func WithLimitPredicate(fn func(net.Conn) bool) Option {
+	return func(o *options) {
+		o.limitPredicate = fn
+	}
+}
+
+// exemptFromLimits replaces the global limiters of the connection with unlimited ones and keeps its per-connection
+// limit unlimited from now on. It must be called before the connection is handed out.
+func (lc *LimitedConnection) exemptFromLimits() {
+	lc.unlimited = true
+	lc.bytesPerSecond = 0
+	lc.globalReadLimiter.Store(newLimiter(0))
+	lc.globalWriteLimiter.Store(newLimiter(0))
+}
@@ -0,0 +1,70 @@
+package limitedlistener
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// portConn is a net.Conn reporting a TCP remote address with the given source port.
+type portConn struct {
+	net.Conn
+	port int
+}
+
+func (c portConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: c.port}
+}
+
+// TestLimitPredicate verifies that connections from odd source ports are exempt from the limits while the ones
+// from even source ports are throttled, and that both are tracked.
+func TestLimitPredicate(t *testing.T) {
+	evenPort := func(conn net.Conn) bool {
+		return conn.RemoteAddr().(*net.TCPAddr).Port%2 == 0
+	}
+	listener, err := NewLimitedListener(nil, 100_000, 1_000, WithLimitPredicate(evenPort))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	conns := map[int]*LimitedConnection{}
+	for _, port := range []int{40001, 40002} {
+		server, client := net.Pipe()
+		defer client.Close()
+		go client.Write(make([]byte, 2_000))
+
+		lc, err := listener.admit(portConn{Conn: server, port: port})
+		if err != nil {
+			t.Fatalf("didn't expect error but got one: %v", err)
+		}
+		defer lc.Close()
+		conns[port] = lc
+	}
+
+	if got := listener.Stats().ActiveConnections; got != 2 {
+		t.Errorf("expected both connections to be tracked, got %d", got)
+	}
+
+	for _, port := range []int{40001, 40002} {
+		start := time.Now()
+		if _, err := io.ReadFull(conns[port], make([]byte, 2_000)); err != nil {
+			t.Fatalf("didn't expect error but got one: %v", err)
+		}
+		elapsed := time.Since(start)
+		if port%2 == 0 && elapsed < 900*time.Millisecond {
+			t.Errorf("expected the connection from port %d to be throttled, took %v", port, elapsed)
+		}
+		if port%2 == 1 && elapsed > 200*time.Millisecond {
+			t.Errorf("expected the connection from port %d to be unlimited, took %v", port, elapsed)
+		}
+	}
+
+	listener.SetLimits(100_000, 500)
+	if got := conns[40001].Snapshot().PerConnLimit; got != 0 {
+		t.Errorf("expected SetLimits to leave the exempt connection unlimited, got %d", got)
+	}
+	if got := conns[40002].Snapshot().PerConnLimit; got != 500 {
+		t.Errorf("expected SetLimits to update the limited connection, got %d", got)
+	}
+}
@@ -0,0 +1,71 @@
+package limitedlistener
+
+import (
+	"io"
+	"sync"
+)
+
+// WithPriorityPrefix lets clients declare their priority in the first length bytes they send, for protocols with
+// client-declared QoS. The first Read of the connection reads the prefix and gives the connection the
+// per-connection limit fn returns for it, pinned against later SetLimits calls as with PinLimit; a negative limit
+// keeps the listener's one and zero removes it. The prefix is not lost: the connection returns it first, charged
+// like any other data.
+//
+// Accept doesn't wait for the prefix, so a silent client can't stall the accept loop. If the connection fails or
+// closes before the prefix is complete, Read returns the error of the underlying connection.
+func WithPriorityPrefix(length int, fn func(prefix []byte) int) Option {
+	return func(o *options) {
+		o.priorityLength = length
+		o.priorityLimit = fn
+	}
+}
+
+// applyPriority pins the connection to the per-connection limit fn maps prefix to, unless it is negative.
+func (lc *LimitedConnection) applyPriority(fn func(prefix []byte) int, prefix []byte) {
+	if limit := fn(prefix); limit >= 0 {
+		lc.PinLimit(true)
+		setConnRate(lc, limit)
+	}
+}
+
+// priorityPrefix is the priority prefix of a connection, read from the underlying connection on its first read and
+// returned before the rest of the data. fn is nil for connections the limits don't apply to.
+type priorityPrefix struct {
+	length  int
+	fn      func(prefix []byte) int
+	once    sync.Once
+	pending []byte
+	err     error
+}
+
+// readConn reads from the underlying connection. On a connection with a priority prefix, the first call reads the
+// prefix and applies it, and the prefix is returned before the rest of the data.
+func (lc *LimitedConnection) readConn(b []byte) (int, error) {
+	p := lc.priority
+	if p == nil {
+		return lc.Conn.Read(b)
+	}
+	p.once.Do(func() { lc.readPriority(p) })
+	if p.err != nil {
+		return 0, p.err
+	}
+	if len(p.pending) > 0 {
+		n := copy(b, p.pending)
+		p.pending = p.pending[n:]
+		return n, nil
+	}
+	return lc.Conn.Read(b)
+}
+
+// readPriority reads the prefix of p from the underlying connection and applies it.
+func (lc *LimitedConnection) readPriority(p *priorityPrefix) {
+	prefix := make([]byte, p.length)
+	if _, err := io.ReadFull(lc.Conn, prefix); err != nil {
+		p.err = err
+		return
+	}
+	p.pending = prefix
+	if p.fn != nil {
+		lc.applyPriority(p.fn, prefix)
+	}
+}
@@ -0,0 +1,131 @@
+package limitedlistener
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// queueListener is a net.Listener handing out the connections sent on its channel.
+type queueListener struct {
+	pipeListener
+	conns chan net.Conn
+}
+
+func (l queueListener) Accept() (net.Conn, error) {
+	return <-l.conns, nil
+}
+
+// TestPriorityPrefix verifies that the declared priority sets the per-connection limit on the first Read, that the
+// prefix is still readable by the application and that a silent client doesn't hold back Accept.
+func TestPriorityPrefix(t *testing.T) {
+	conns := make(chan net.Conn, 2)
+	listener, err := NewLimitedListener(queueListener{conns: conns}, 100_000, 10_000, WithPriorityPrefix(1, func(prefix []byte) int {
+		return int(prefix[0]) * 1_000
+	}))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	silent, silentClient := net.Pipe()
+	defer silentClient.Close()
+	conns <- silent
+
+	server, client := net.Pipe()
+	defer client.Close()
+	conns <- server
+	go client.Write([]byte{3, 'h', 'i'})
+
+	start := time.Now()
+	silentConn, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer conn.Close()
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("expected Accept not to wait for the prefix of a silent client, but it took %v", elapsed)
+	}
+
+	buf := make([]byte, 3)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if !bytes.Equal(buf, []byte{3, 'h', 'i'}) {
+		t.Errorf("expected the prefix to be read back before the data, got %q", buf)
+	}
+
+	lc := conn.(*LimitedConnection)
+	if limit := lc.Limiter().Limit(); limit != rate.Limit(3_000) {
+		t.Errorf("expected priority 3 to map to a limit of 3000, got %v", limit)
+	}
+	listener.SetLimits(100_000, 5_000)
+	if limit := lc.Limiter().Limit(); limit != rate.Limit(3_000) {
+		t.Errorf("expected the priority limit to survive SetLimits, got %v", limit)
+	}
+
+	silentClient.Close()
+	if _, err := silentConn.Read(buf); err == nil {
+		t.Errorf("expected an error reading a connection closed before its prefix")
+	}
+	silentConn.Close()
+}
+
+// TestPriorityPrefixValidation verifies that a priority prefix without a mapping function is rejected.
+func TestPriorityPrefixValidation(t *testing.T) {
+	if _, err := NewLimitedListener(nil, 1_000, 100, WithPriorityPrefix(1, nil)); !errors.Is(err, ErrLimitOutOfRange) {
+		t.Errorf("expected ErrLimitOutOfRange without a mapping function, got %v", err)
+	}
+}
+
+// TestPriorityPrefixKeepsConnType verifies that a connection with a priority prefix still embeds the accepted
+// connection, so its transport-specific methods stay reachable.
+func TestPriorityPrefixKeepsConnType(t *testing.T) {
+	listener, err := Listen("tcp", "127.0.0.1:0", WithLimits(100_000, 10_000), WithPriorityPrefix(1, func(prefix []byte) int {
+		return int(prefix[0]) * 1_000
+	}))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer listener.Close()
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	defer client.Close()
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer conn.Close()
+
+	tcpConn, ok := conn.(*LimitedConnection).Conn.(*net.TCPConn)
+	if !ok {
+		t.Fatalf("expected the accepted *net.TCPConn to be embedded, got %T", conn.(*LimitedConnection).Conn)
+	}
+	if err := tcpConn.SetNoDelay(true); err != nil {
+		t.Errorf("didn't expect error but got one: %v", err)
+	}
+
+	client.Write([]byte{2, 'o', 'k'})
+	buf := make([]byte, 3)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if !bytes.Equal(buf, []byte{2, 'o', 'k'}) {
+		t.Errorf("expected the prefix to be read back before the data, got %q", buf)
+	}
+	if limit := conn.(*LimitedConnection).Limiter().Limit(); limit != rate.Limit(2_000) {
+		t.Errorf("expected priority 2 to map to a limit of 2000, got %v", limit)
+	}
+}
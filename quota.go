@@ -0,0 +1,48 @@
+package limitedlistener
+
+import "fmt"
+
+var (
+	ErrQuotaExceeded     = fmt.Errorf("total byte quota exceeded")
+	ErrConnQuotaExceeded = fmt.Errorf("connection byte quota exceeded")
+)
+
+// WithTotalByteQuota limits the number of bytes all connections of the listener may transfer in total.
+// Once the quota is reached Accept returns ErrQuotaExceeded; connections already accepted keep working
+// unless WithCloseOnQuota is also set.
+func WithTotalByteQuota(quota int64) Option {
+	return func(o *options) {
+		o.totalByteQuota = quota
+	}
+}
+
+// WithCloseOnQuota closes every tracked connection as soon as the total byte quota is reached.
+func WithCloseOnQuota() Option {
+	return func(o *options) {
+		o.closeOnQuota = true
+	}
+}
+
+// WithPerConnByteQuota limits the number of bytes a single connection may read and write in total. Once a
+// connection has transferred at least quota bytes, its next Read or Write closes it and returns ErrConnQuotaExceeded.
+// The operation crossing the quota completes normally.
+func WithPerConnByteQuota(quota int64) Option {
+	return func(o *options) {
+		o.perConnByteQuota = quota
+	}
+}
+
+// quotaExceeded reports whether the listener has transferred at least its total byte quota.
+func (l *LimitedListener) quotaExceeded() bool {
+	return l.opts.totalByteQuota > 0 && l.totalBytes.Load() >= l.opts.totalByteQuota
+}
+
+// checkConnQuota closes the connection and returns ErrConnQuotaExceeded if it has used up its byte quota.
+func (lc *LimitedConnection) checkConnQuota() error {
+	quota := lc.options().perConnByteQuota
+	if quota <= 0 || lc.bytesRead.Load()+lc.bytesWritten.Load() < quota {
+		return nil
+	}
+	lc.Close()
+	return ErrConnQuotaExceeded
+}
@@ -0,0 +1,118 @@
+package limitedlistener
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+)
+
+// TestTotalByteQuota verifies that Accept is rejected once the connections transferred more than the quota.
+func TestTotalByteQuota(t *testing.T) {
+	limitedListener, err := Listen("tcp", "127.0.0.1:0", WithTotalByteQuota(1_000))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer limitedListener.Close()
+
+	go func() {
+		conn, err := net.Dial("tcp", limitedListener.Addr().String())
+		if err != nil {
+			t.Errorf("dial error: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		conn.Write(make([]byte, 1_500))
+	}()
+
+	conn, err := limitedListener.Accept()
+	if err != nil {
+		t.Fatalf("accept error: %v", err)
+	}
+	defer conn.Close()
+
+	n, err := io.Copy(io.Discard, conn)
+	if err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if n != 1_500 || limitedListener.TotalBytes() != 1_500 {
+		t.Errorf("expected 1500 bytes transferred, but got %d (total %d)", n, limitedListener.TotalBytes())
+	}
+
+	if _, err := limitedListener.Accept(); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected %v, but got %v", ErrQuotaExceeded, err)
+	}
+}
+
+// TestCloseOnQuota verifies that existing connections are closed when the quota is crossed and WithCloseOnQuota is set.
+func TestCloseOnQuota(t *testing.T) {
+	limitedListener, err := Listen("tcp", "127.0.0.1:0", WithTotalByteQuota(1_000), WithCloseOnQuota())
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer limitedListener.Close()
+
+	clients := make([]net.Conn, 2)
+	servers := make([]net.Conn, 2)
+	for i := range clients {
+		clients[i], err = net.Dial("tcp", limitedListener.Addr().String())
+		if err != nil {
+			t.Fatalf("dial error: %v", err)
+		}
+		defer clients[i].Close()
+
+		servers[i], err = limitedListener.Accept()
+		if err != nil {
+			t.Fatalf("accept error: %v", err)
+		}
+		defer servers[i].Close()
+	}
+
+	if _, err := clients[0].Write(make([]byte, 1_000)); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if _, err := io.ReadFull(servers[0], make([]byte, 1_000)); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+
+	if _, err := servers[1].Read(make([]byte, 1)); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("expected the idle connection to be closed, but got %v", err)
+	}
+	if len(limitedListener.connections) != 0 {
+		t.Errorf("expected 0 connections but got %d", len(limitedListener.connections))
+	}
+}
+
+// TestPerConnByteQuota verifies that a connection past its byte quota fails its next operation with
+// ErrConnQuotaExceeded, is closed and is removed from the listener.
+func TestPerConnByteQuota(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 1_000_000, 1_000_000, WithPerConnByteQuota(1_000))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+
+	go client.Write(make([]byte, 1_200))
+
+	if _, err := io.ReadFull(lc, make([]byte, 1_200)); err != nil {
+		t.Fatalf("expected the read crossing the quota to succeed, got %v", err)
+	}
+
+	if _, err := lc.Read(make([]byte, 10)); !errors.Is(err, ErrConnQuotaExceeded) {
+		t.Errorf("expected ErrConnQuotaExceeded, got %v", err)
+	}
+	if _, err := lc.Write(make([]byte, 10)); !errors.Is(err, ErrConnQuotaExceeded) {
+		t.Errorf("expected ErrConnQuotaExceeded on write, got %v", err)
+	}
+	if _, err := client.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("expected the connection to be closed, got %v", err)
+	}
+	if active := listener.Stats().ActiveConnections; active != 0 {
+		t.Errorf("expected the connection to be removed from the listener, got %d active", active)
+	}
+}
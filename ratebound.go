@@ -0,0 +1,55 @@
+package limitedlistener
+
+import (
+	"sync"
+	"time"
+)
+
+// WithOnRateBound calls fn when a connection spends more than threshold (a fraction between 0 and 1) of a window
+// waiting on its per-connection limiter. Such a connection is rate-bound rather than network-bound: raising its
+// per-connection limit would speed it up. The callback runs on the reading goroutine and should return quickly.
+func WithOnRateBound(threshold float64, window time.Duration, fn func(*LimitedConnection)) Option {
+	return func(o *options) {
+		o.rateBoundThreshold = threshold
+		o.rateBoundWindow = window
+		o.onRateBound = fn
+	}
+}
+
+// rateBoundTracker accumulates the time a connection waited on its per-connection limiter in the current window.
+type rateBoundTracker struct {
+	mu          sync.Mutex
+	windowStart time.Time
+	waited      time.Duration
+}
+
+// observePerConnWait records a wait on the per-connection limiter and fires the rate-bound callback
+// when the window closes with the waited share above the threshold.
+func (lc *LimitedConnection) observePerConnWait(d time.Duration) {
+	o := lc.options()
+	if o.onRateBound == nil || o.rateBoundWindow <= 0 {
+		return
+	}
+
+	t := &lc.rateBound
+	t.mu.Lock()
+	now := time.Now()
+	if t.windowStart.IsZero() {
+		t.windowStart = now.Add(-d)
+	}
+	t.waited += d
+
+	elapsed := now.Sub(t.windowStart)
+	if elapsed < o.rateBoundWindow {
+		t.mu.Unlock()
+		return
+	}
+	bound := t.waited.Seconds()/elapsed.Seconds() > o.rateBoundThreshold
+	t.windowStart = now
+	t.waited = 0
+	t.mu.Unlock()
+
+	if bound {
+		o.onRateBound(lc)
+	}
+}
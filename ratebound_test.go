@@ -0,0 +1,41 @@
+package limitedlistener
+
+import (
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestOnRateBound verifies that a fast client hitting a tight per-connection limit is reported as rate-bound.
+func TestOnRateBound(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	var fired atomic.Int32
+	var reported atomic.Pointer[LimitedConnection]
+	listener, err := NewLimitedListener(nil, 1_000_000, 1_000, WithOnRateBound(0.5, 200*time.Millisecond, func(lc *LimitedConnection) {
+		fired.Add(1)
+		reported.Store(lc)
+	}))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	go client.Write(make([]byte, 1_500))
+
+	if _, err := io.ReadFull(lc, make([]byte, 1_500)); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+
+	if fired.Load() == 0 {
+		t.Fatalf("expected the rate-bound callback to fire")
+	}
+	if reported.Load() != lc {
+		t.Errorf("expected the callback to receive the throttled connection")
+	}
+}
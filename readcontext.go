@@ -0,0 +1,12 @@
+package limitedlistener
+
+import "context"
+
+// ReadContext reads like Read, but waits on the limiters with ctx instead of the listener's context, so a
+// request-scoped cancellation aborts a read blocked by throttling right away. The error then wraps ctx.Err()
+// and can be matched with errors.Is; the bytes already read are returned along with it.
+//
+// Only the limiter waits observe ctx: the underlying read is still bounded by the connection's deadlines.
+func (lc *LimitedConnection) ReadContext(ctx context.Context, b []byte) (int, error) {
+	return lc.timedRead(ctx, b)
+}
@@ -0,0 +1,42 @@
+package limitedlistener
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestReadContext verifies that cancelling the context aborts a read waiting on the limiters promptly and
+// that the returned error wraps the context error.
+func TestReadContext(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 100, 100)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	listener.globalReadLimiter.ReserveN(time.Now(), 100)
+	go client.Write(make([]byte, 100))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	n, err := lc.ReadContext(ctx, make([]byte, 100))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected an error wrapping context.Canceled, got %v", err)
+	}
+	if n != 100 {
+		t.Errorf("expected the bytes already read to be returned, got %d", n)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("expected the read to return promptly on cancellation, took %v", elapsed)
+	}
+}
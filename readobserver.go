@@ -0,0 +1,18 @@
+package limitedlistener
+
+// WithReadObserver sets a function called with the bytes just read after every successful read of a connection,
+// for example to compute a checksum or meter the traffic without wrapping the reader. It runs on the reading
+// goroutine before the limiter wait, so it should be fast. p is the caller's buffer: fn must not modify it nor
+// retain it after returning.
+func WithReadObserver(fn func(lc *LimitedConnection, p []byte)) Option {
+	return func(o *options) {
+		o.readObserver = fn
+	}
+}
+
+// observeRead passes the bytes just read to the read observer, if any.
+func (lc *LimitedConnection) observeRead(p []byte) {
+	if observer := lc.options().readObserver; observer != nil {
+		observer(lc, p)
+	}
+}
@@ -0,0 +1,47 @@
+package limitedlistener
+
+import (
+	"hash/crc32"
+	"io"
+	"net"
+	"testing"
+)
+
+// TestReadObserver verifies that the observer sees every byte read, in order, and that the observed total matches
+// the byte counter of the connection.
+func TestReadObserver(t *testing.T) {
+	server, client := net.Pipe()
+
+	var observed int64
+	checksum := crc32.NewIEEE()
+	listener, err := NewLimitedListener(nil, 1_000_000, 100_000, WithReadObserver(func(lc *LimitedConnection, p []byte) {
+		observed += int64(len(p))
+		checksum.Write(p)
+	}))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	data := make([]byte, 50_000)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	go func() {
+		client.Write(data)
+		client.Close()
+	}()
+
+	if _, err := io.ReadFull(lc, make([]byte, len(data))); err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	if observed != lc.BytesRead() || observed != int64(len(data)) {
+		t.Errorf("expected %d observed bytes matching BytesRead, got %d observed and %d read", len(data), observed, lc.BytesRead())
+	}
+	if got, want := checksum.Sum32(), crc32.ChecksumIEEE(data); got != want {
+		t.Errorf("expected the observed bytes to match the data sent, checksum %08x != %08x", got, want)
+	}
+}
@@ -0,0 +1,25 @@
+package limitedlistener
+
+import "context"
+
+// WithMaxConcurrentReads caps the number of reads in progress at the same time across all the connections of
+// the listener, bounding the memory held in read buffers independently of the bandwidth limits. A read holds its
+// slot from the underlying read until its limiter waits are done; excess readers block until a slot frees.
+func WithMaxConcurrentReads(n int) Option {
+	return func(o *options) {
+		o.maxConcurrentReads = n
+	}
+}
+
+// acquireReadSlot blocks until the listener owning the connection has a free read slot, returning the function
+// freeing it. It returns at once if the listener has no concurrent read cap.
+func (lc *LimitedConnection) acquireReadSlot(ctx context.Context) (func(), error) {
+	parent := lc.parentListener.Load()
+	if parent == nil || parent.readSlots == nil {
+		return func() {}, nil
+	}
+	if err := parent.readSlots.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+	return func() { parent.readSlots.Release(1) }, nil
+}
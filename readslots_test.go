@@ -0,0 +1,59 @@
+package limitedlistener
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// concurrencyConn is a net.Conn whose reads take a while and record how many of them run at the same time.
+type concurrencyConn struct {
+	net.Conn
+	active, peak *atomic.Int64
+}
+
+func (c concurrencyConn) Read(b []byte) (int, error) {
+	active := c.active.Add(1)
+	defer c.active.Add(-1)
+	for {
+		peak := c.peak.Load()
+		if active <= peak || c.peak.CompareAndSwap(peak, active) {
+			break
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	return len(b), nil
+}
+
+// TestMaxConcurrentReads verifies that no more than the configured number of reads proceed at the same time.
+func TestMaxConcurrentReads(t *testing.T) {
+	const maxReads = 3
+
+	listener, err := NewLimitedListener(nil, 1_000_000, 1_000_000, WithMaxConcurrentReads(maxReads))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	var active, peak atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		lc := admitConn(t, listener, concurrencyConn{active: &active, peak: &peak})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				if _, err := lc.Read(make([]byte, 100)); err != nil {
+					t.Errorf("read error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := peak.Load(); got != maxReads {
+		t.Errorf("expected at most %d concurrent reads, and that many to run, got a peak of %d", maxReads, got)
+	}
+}
@@ -0,0 +1,64 @@
+package limitedlistener
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+var ErrRateWaitTimeout = fmt.Errorf("rate limiter wait exceeded the maximum read wait")
+
+// WithMaxReadWait caps how long a single Read may wait for the limiters. When not even one byte can be granted
+// within d, Read blocks for d and returns (0, ErrRateWaitTimeout) so the caller can react, e.g. by sending a keepalive.
+// Unlike a read deadline, the cap applies to every call separately. A read deadline, a context passed to ReadContext
+// or closing the connection still ends the wait early with their own error.
+//
+// The read is sized to what the limiters can grant within d, so the cap holds unless other connections consume
+// the shared global budget at the same time.
+func WithMaxReadWait(d time.Duration) Option {
+	return func(o *options) {
+		o.maxReadWait = d
+	}
+}
+
+// affordableWithin returns how many of n bytes all limiters can grant within d.
+func affordableWithin(d time.Duration, n int, limiters ...*rate.Limiter) int {
+	at := time.Now().Add(d)
+	for _, limiter := range limiters {
+		if limiter.Limit() == rate.Inf {
+			continue
+		}
+		n = min(n, max(int(limiter.TokensAt(at)), 0))
+	}
+	return n
+}
+
+// waitReadCap blocks for d when no byte is affordable within the maximum read wait, then returns ErrRateWaitTimeout.
+// It returns os.ErrDeadlineExceeded once the read deadline passes, net.ErrClosed if the connection is closed, or the
+// context error, whichever comes first.
+func (lc *LimitedConnection) waitReadCap(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	var timeout <-chan time.Time
+	if deadline := lc.readDeadline.Load(); deadline != 0 {
+		deadlineTimer := time.NewTimer(time.Until(time.Unix(0, deadline)))
+		defer deadlineTimer.Stop()
+		timeout = deadlineTimer.C
+	}
+
+	select {
+	case <-timer.C:
+		return ErrRateWaitTimeout
+	case <-lc.closing:
+		return net.ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timeout:
+		return os.ErrDeadlineExceeded
+	}
+}
@@ -0,0 +1,111 @@
+package limitedlistener
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestMaxReadWait verifies that reads under a tight limit never wait longer than the configured cap.
+func TestMaxReadWait(t *testing.T) {
+	const maxWait = 100 * time.Millisecond
+
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 100, 100, WithMaxReadWait(maxWait))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	go client.Write(make([]byte, 1_000))
+
+	lc.Limiter().AllowN(time.Now(), 100)
+
+	start := time.Now()
+	n, err := lc.Read(make([]byte, 100))
+	if elapsed := time.Since(start); elapsed > 2*maxWait {
+		t.Errorf("expected the read to return within %v, but it took %v", maxWait, elapsed)
+	}
+	if n > 10 {
+		t.Errorf("expected at most 10 bytes to be affordable within %v, but got %d", maxWait, n)
+	}
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc.Limiter().ReserveN(time.Now(), 100)
+
+	start = time.Now()
+	n, err = lc.Read(make([]byte, 100))
+	if n != 0 || !errors.Is(err, ErrRateWaitTimeout) {
+		t.Errorf("expected (0, %v), but got (%d, %v)", ErrRateWaitTimeout, n, err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*maxWait {
+		t.Errorf("expected the read to time out within %v, but it took %v", maxWait, elapsed)
+	}
+}
+
+// TestMaxReadWaitHonorsContext verifies that the wait for the read cap ends with the context, the read deadline or
+// closing the connection, whichever comes first.
+func TestMaxReadWaitHonorsContext(t *testing.T) {
+	const maxWait = time.Second
+
+	listener, err := NewLimitedListener(nil, 100, 100, WithMaxReadWait(maxWait))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	newDrained := func() (*LimitedConnection, net.Conn) {
+		server, client := net.Pipe()
+		lc := admitConn(t, listener, server)
+		for i := 0; i < 3; i++ {
+			lc.Limiter().ReserveN(time.Now(), 100)
+		}
+		return lc, client
+	}
+
+	lc, client := newDrained()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	start := time.Now()
+	_, err = lc.ReadContext(ctx, make([]byte, 100))
+	cancel()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(start); elapsed > maxWait/2 {
+		t.Errorf("expected the read to end with the context, but it took %v", elapsed)
+	}
+	lc.Close()
+	client.Close()
+
+	lc, client = newDrained()
+	lc.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+	start = time.Now()
+	_, err = lc.Read(make([]byte, 100))
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("expected %v, got %v", os.ErrDeadlineExceeded, err)
+	}
+	if elapsed := time.Since(start); elapsed > maxWait/2 {
+		t.Errorf("expected the read to end with the deadline, but it took %v", elapsed)
+	}
+	client.Close()
+
+	lc, client = newDrained()
+	defer client.Close()
+	time.AfterFunc(20*time.Millisecond, func() { lc.Close() })
+	start = time.Now()
+	_, err = lc.Read(make([]byte, 100))
+	if !errors.Is(err, net.ErrClosed) {
+		t.Errorf("expected %v, got %v", net.ErrClosed, err)
+	}
+	if elapsed := time.Since(start); elapsed > maxWait/2 {
+		t.Errorf("expected the read to end with Close, but it took %v", elapsed)
+	}
+}
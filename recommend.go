@@ -0,0 +1,47 @@
+package limitedlistener
+
+import (
+	"math"
+	"slices"
+)
+
+// RecommendLimits suggests a global and a per-connection limit from the traffic observed so far, for example in
+// measure-only mode, that would have let the given percentile of it through unthrottled. percentile is a fraction:
+// 0.95 picks the 95th percentile of the throughput samples, 1 their peak. The global limit comes from the
+// aggregate samples of the listener and the per-connection one from the samples of the tracked connections,
+// leaving out the intervals a connection was idle. The global limit is never below the per-connection one, so the
+// result can be passed to SetLimits as is.
+//
+// The samples are taken by WithThroughputSampling, over its window of samples intervals, and count only the bytes
+// read, as the limits only throttle reads. It returns zeros without sampling or before the first sample.
+func (l *LimitedListener) RecommendLimits(percentile float64) (global, perConn int) {
+	if l.readThroughput == nil {
+		return 0, 0
+	}
+
+	var samples []float64
+	for _, connection := range l.trackedConnections() {
+		if connection.readThroughput == nil {
+			continue
+		}
+		for _, sample := range connection.readThroughput.values() {
+			if sample > 0 {
+				samples = append(samples, sample)
+			}
+		}
+	}
+	perConn = int(math.Ceil(percentileOf(samples, percentile)))
+	global = int(math.Ceil(percentileOf(l.readThroughput.values(), percentile)))
+	return max(global, perConn), perConn
+}
+
+// percentileOf returns the nearest-rank percentile of samples, clamping percentile to [0, 1], or zero without samples.
+// It sorts samples in place.
+func percentileOf(samples []float64, percentile float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	slices.Sort(samples)
+	rank := int(math.Ceil(min(max(percentile, 0), 1) * float64(len(samples))))
+	return samples[max(rank-1, 0)]
+}
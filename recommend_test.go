@@ -0,0 +1,65 @@
+package limitedlistener
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// TestRecommendLimits verifies that the recommended limits follow the percentiles of the read throughput samples.
+func TestRecommendLimits(t *testing.T) {
+	// The interval is long enough for the sampler never to run, so the test feeds the samples itself.
+	listener, err := NewLimitedListener(nil, 100_000, 10_000, WithThroughputSampling(time.Hour, 10))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer listener.Close()
+
+	if global, perConn := listener.RecommendLimits(0.95); global != 0 || perConn != 0 {
+		t.Errorf("expected no recommendation before any sample, got (%d, %d)", global, perConn)
+	}
+
+	busy, busyClient := net.Pipe()
+	defer busyClient.Close()
+	busyConn := listener.Track(busy)
+	defer busyConn.Close()
+	idle, idleClient := net.Pipe()
+	defer idleClient.Close()
+	idleConn := listener.Track(idle)
+	defer idleConn.Close()
+
+	// The busy connection reads 1000, 2000, 3000 and 4000 bytes/s; the idle one nothing, then 5000 bytes/s.
+	for _, total := range []int64{1_000, 3_000, 6_000, 10_000} {
+		busyConn.readThroughput.sample(total, 1)
+	}
+	idleConn.readThroughput.sample(0, 1)
+	idleConn.readThroughput.sample(5_000, 1)
+	listener.readThroughput.sample(6_000, 1)
+	listener.readThroughput.sample(15_000, 1)
+	// Writes show up in the combined samples only and must not inflate the read limits.
+	busyConn.throughput.sample(1_000_000, 1)
+	listener.throughput.sample(1_000_000, 1)
+
+	tests := []struct {
+		percentile      float64
+		global, perConn int
+	}{
+		{percentile: 0.5, global: 6_000, perConn: 3_000},
+		{percentile: 0.8, global: 9_000, perConn: 4_000},
+		{percentile: 1, global: 9_000, perConn: 5_000},
+	}
+	for _, tt := range tests {
+		global, perConn := listener.RecommendLimits(tt.percentile)
+		if global != tt.global || perConn != tt.perConn {
+			t.Errorf("RecommendLimits(%v) = (%d, %d), want (%d, %d)", tt.percentile, global, perConn, tt.global, tt.perConn)
+		}
+	}
+
+	unsampled, err := NewLimitedListener(nil, 100_000, 10_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	if global, perConn := unsampled.RecommendLimits(0.95); global != 0 || perConn != 0 {
+		t.Errorf("expected no recommendation without sampling, got (%d, %d)", global, perConn)
+	}
+}
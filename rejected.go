@@ -0,0 +1,69 @@
+package limitedlistener
+
+import (
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// rejectResponseTimeout bounds the writes of the reject responder, so a slow client can't stall the accept loop.
+const rejectResponseTimeout = 500 * time.Millisecond
+
+// errRejected is returned internally when a connection was closed by Accept and the next one should be accepted.
+var errRejected = fmt.Errorf("connection rejected")
+
+// rejectCounters counts the connections closed by Accept, per reason.
+type rejectCounters struct {
+	maxConnections atomic.Int64
+	filter         atomic.Int64
+	quota          atomic.Int64
+	admission      atomic.Int64
+}
+
+// WithMaxConnections limits the number of connections tracked by the listener at the same time. Connections
+// accepted while the limit is reached are closed right away and Accept waits for the next one.
+func WithMaxConnections(n int) Option {
+	return func(o *options) {
+		o.maxConnections = n
+	}
+}
+
+// WithAcceptFilter sets a function deciding whether an accepted connection is handed out, for example based on
+// its remote address. Connections for which filter returns false are closed and Accept waits for the next one.
+func WithAcceptFilter(filter func(net.Conn) bool) Option {
+	return func(o *options) {
+		o.acceptFilter = filter
+	}
+}
+
+// WithRejectResponder sets a function writing a short message, such as an HTTP 503 or a protocol-specific busy
+// message, to connections rejected because the listener is at capacity: max connections, admission controller,
+// byte quota or StopAccepting. It is called before the connection is closed, with a write deadline
+// of rejectResponseTimeout already set. Connections refused by the accept filter are closed without a response.
+func WithRejectResponder(responder func(net.Conn)) Option {
+	return func(o *options) {
+		o.rejectResponder = responder
+	}
+}
+
+// reject closes a connection refused for lack of capacity, giving the reject responder a chance to answer first.
+func (l *LimitedListener) reject(conn net.Conn) {
+	if responder := l.opts.rejectResponder; responder != nil {
+		conn.SetWriteDeadline(time.Now().Add(rejectResponseTimeout))
+		responder(conn)
+	}
+	conn.Close()
+}
+
+// RejectedStats returns how many connections Accept closed instead of handing them out, keyed by reason:
+// "max_connections", "filter", "quota" and "admission". Every reason is present, with zero if it never occurred.
+// Connections only delayed by the accept rate are not rejected, see PendingAccepts.
+func (l *LimitedListener) RejectedStats() map[string]int64 {
+	return map[string]int64{
+		"max_connections": l.rejected.maxConnections.Load(),
+		"filter":          l.rejected.filter.Load(),
+		"quota":           l.rejected.quota.Load(),
+		"admission":       l.rejected.admission.Load(),
+	}
+}
@@ -0,0 +1,154 @@
+package limitedlistener
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestRejectedMaxConnections verifies that a connection over the maximum is closed and counted, and that
+// Accept hands out the next connection once a slot is free.
+func TestRejectedMaxConnections(t *testing.T) {
+	listener, err := Listen("tcp", "127.0.0.1:0", WithMaxConnections(1))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer listener.Close()
+
+	dial := func() net.Conn {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("dial error: %v", err)
+		}
+		return conn
+	}
+
+	first := dial()
+	defer first.Close()
+	accepted, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("accept error: %v", err)
+	}
+
+	next := make(chan net.Conn, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			next <- conn
+		}
+	}()
+
+	rejected := dial()
+	defer rejected.Close()
+	rejected.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := rejected.Read(make([]byte, 1)); err == nil {
+		t.Errorf("expected the connection over the maximum to be closed")
+	}
+
+	stats := listener.RejectedStats()
+	if stats["max_connections"] != 1 {
+		t.Errorf("expected 1 max_connections rejection, got %v", stats)
+	}
+	for _, reason := range []string{"filter", "quota", "admission"} {
+		if stats[reason] != 0 {
+			t.Errorf("expected no %s rejection, got %d", reason, stats[reason])
+		}
+	}
+
+	accepted.Close()
+	third := dial()
+	defer third.Close()
+
+	select {
+	case conn := <-next:
+		conn.Close()
+	case <-time.After(time.Second):
+		t.Fatalf("expected Accept to return the next connection once a slot was free")
+	}
+}
+
+// TestRejectedFilter verifies that connections refused by the accept filter are closed and counted.
+func TestRejectedFilter(t *testing.T) {
+	calls := 0
+	listener, err := NewLimitedListener(pipeListener{}, 1_000, 1_000, WithAcceptFilter(func(net.Conn) bool {
+		calls++
+		return calls > 2
+	}))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("accept error: %v", err)
+	}
+	defer conn.Close()
+
+	if got := listener.RejectedStats()["filter"]; got != 2 {
+		t.Errorf("expected 2 filter rejections, got %d", got)
+	}
+}
+
+// TestRejectResponder verifies that a client rejected at capacity receives the responder's message before the close.
+func TestRejectResponder(t *testing.T) {
+	const busy = "HTTP/1.1 503 Service Unavailable\r\nContent-Length: 0\r\n\r\n"
+
+	listener, err := Listen("tcp", "127.0.0.1:0", WithMaxConnections(1), WithRejectResponder(func(conn net.Conn) {
+		io.WriteString(conn, busy)
+	}))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer listener.Close()
+
+	first, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	defer first.Close()
+	accepted, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("accept error: %v", err)
+	}
+	defer accepted.Close()
+
+	go listener.Accept()
+
+	rejected, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	defer rejected.Close()
+	rejected.SetReadDeadline(time.Now().Add(time.Second))
+
+	got, err := io.ReadAll(rejected)
+	if err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if string(got) != busy {
+		t.Errorf("expected the rejection message %q, got %q", busy, got)
+	}
+}
+
+// TestRejectResponderTimeout verifies that a client not reading the rejection message can't block the responder.
+func TestRejectResponderTimeout(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 1_000, 1_000, WithRejectResponder(func(conn net.Conn) {
+		conn.Write(make([]byte, 1_000))
+	}))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	server, client := net.Pipe()
+	defer client.Close()
+
+	start := time.Now()
+	listener.reject(server)
+	if elapsed := time.Since(start); elapsed > 2*rejectResponseTimeout {
+		t.Errorf("expected the responder to be cut off after %v, but it took %v", rejectResponseTimeout, elapsed)
+	}
+	if _, err := server.Write([]byte{0}); err == nil {
+		t.Errorf("expected the rejected connection to be closed")
+	}
+}
@@ -0,0 +1,31 @@
+package limitedlistener
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+var ErrReservationTooLarge = fmt.Errorf("reservation is larger than the global burst")
+
+// ReserveGlobal reserves n bytes of the global read bandwidth ahead of a large transfer. The tokens are taken
+// from the shared limiter immediately, so reads of all connections queue behind the reservation; Delay reports
+// how long the caller should wait before starting the transfer it reserved for.
+//
+// Cancel returns the tokens to the limiter as far as possible: tokens already covered by reservations made
+// after this one are not restored, and cancelling after the delay has elapsed has no effect.
+// On an unlimited listener the reservation always succeeds without delay.
+//
+// It returns ErrLimitOutOfRange if n is not positive and ErrReservationTooLarge if n exceeds the global burst.
+func (l *LimitedListener) ReserveGlobal(n int) (*rate.Reservation, error) {
+	if n <= 0 {
+		return nil, ErrLimitOutOfRange
+	}
+
+	reservation := l.globalReadLimiter.ReserveN(time.Now(), n)
+	if !reservation.OK() {
+		return nil, ErrReservationTooLarge
+	}
+	return reservation, nil
+}
@@ -0,0 +1,44 @@
+package limitedlistener
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestReserveGlobal verifies that a reservation delays the next one by its size and that cancelling it
+// returns the tokens to the global limiter.
+func TestReserveGlobal(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 1_000, 1_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	first, err := listener.ReserveGlobal(1_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	if delay := first.Delay(); delay != 0 {
+		t.Errorf("expected the first reservation to fit in the burst, got a delay of %v", delay)
+	}
+
+	second, err := listener.ReserveGlobal(500)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	if delay := second.Delay(); delay < 400*time.Millisecond || delay > 500*time.Millisecond {
+		t.Errorf("expected a delay of about 500ms, got %v", delay)
+	}
+
+	second.Cancel()
+	if tokens := listener.globalReadLimiter.Tokens(); tokens < -1 || tokens > 1 {
+		t.Errorf("expected cancelling to return the reserved tokens, got %v tokens", tokens)
+	}
+
+	if _, err := listener.ReserveGlobal(1_001); !errors.Is(err, ErrReservationTooLarge) {
+		t.Errorf("expected ErrReservationTooLarge, got %v", err)
+	}
+	if _, err := listener.ReserveGlobal(0); !errors.Is(err, ErrLimitOutOfRange) {
+		t.Errorf("expected ErrLimitOutOfRange, got %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package limitedlistener
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/time/rate"
+)
+
+// WithRoundRobinScheduling serves the global read budget to the connections in strict round robin instead of
+// first come, first served. A central goroutine hands out read permits one connection at a time, so a connection
+// issuing many concurrent reads gets no more of the global budget than one issuing a single read.
+// Reads are capped at quantum bytes, which is the share a connection receives per turn.
+func WithRoundRobinScheduling(quantum int) Option {
+	return func(o *options) {
+		o.roundRobinQuantum = quantum
+	}
+}
+
+// roundRobinScheduler charges reads to the global limiter in round-robin order across connections.
+type roundRobinScheduler struct {
+	limiter *rate.Limiter
+	wake    chan struct{}
+	done    <-chan struct{}
+
+	// mu guards queues and ring. A connection has an entry in queues, possibly empty, as long as it is in ring.
+	mu     sync.Mutex
+	queues map[*LimitedConnection][]*readPermit
+	ring   []*LimitedConnection
+}
+
+// readPermit is a pending request to charge n bytes read by a connection to the global limiter.
+type readPermit struct {
+	n         int
+	granted   chan error
+	cancelled atomic.Bool
+}
+
+// newRoundRobinScheduler starts a scheduler charging limiter until done is closed.
+func newRoundRobinScheduler(limiter *rate.Limiter, done <-chan struct{}) *roundRobinScheduler {
+	s := &roundRobinScheduler{
+		limiter: limiter,
+		wake:    make(chan struct{}, 1),
+		done:    done,
+		queues:  make(map[*LimitedConnection][]*readPermit),
+	}
+	go s.run()
+	return s
+}
+
+// acquire blocks until the scheduler charged n bytes for lc to the global limiter.
+func (s *roundRobinScheduler) acquire(ctx context.Context, lc *LimitedConnection, n int) error {
+	permit := &readPermit{n: n, granted: make(chan error, 1)}
+
+	s.mu.Lock()
+	if _, ok := s.queues[lc]; !ok {
+		s.ring = append(s.ring, lc)
+	}
+	s.queues[lc] = append(s.queues[lc], permit)
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	select {
+	case err := <-permit.granted:
+		return err
+	case <-ctx.Done():
+		permit.cancelled.Store(true)
+		return ctx.Err()
+	case <-s.done:
+		return net.ErrClosed
+	}
+}
+
+// next pops the first permit of the connection whose turn it is and moves that connection to the back of the ring.
+// A connection keeps its place in the ring after its queue drains, so one that immediately issues its next read
+// does not lose its turn; it is only dropped from the ring when its turn comes and it has nothing queued.
+func (s *roundRobinScheduler) next() (*readPermit, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for len(s.ring) > 0 {
+		lc := s.ring[0]
+		s.ring = s.ring[1:]
+
+		queue := s.queues[lc]
+		if len(queue) == 0 {
+			delete(s.queues, lc)
+			continue
+		}
+		s.queues[lc] = queue[1:]
+		s.ring = append(s.ring, lc)
+		return queue[0], true
+	}
+	return nil, false
+}
+
+func (s *roundRobinScheduler) run() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.done
+		cancel()
+	}()
+
+	for {
+		permit, ok := s.next()
+		if !ok {
+			select {
+			case <-s.wake:
+				continue
+			case <-s.done:
+				return
+			}
+		}
+		if permit.cancelled.Load() {
+			continue
+		}
+		permit.granted <- waitN(ctx, s.limiter, permit.n)
+	}
+}
+
+// waitGlobal charges n bytes read by the connection to the global limiter, through the round-robin scheduler
+// of the listener owning the connection if it has one. The scheduler only serves the listener's own global
+// limiter, so a connection still wired to another one waits on it directly.
+func (lc *LimitedConnection) waitGlobal(ctx context.Context, limiter *rate.Limiter, n int) error {
+	if parent := lc.parentListener.Load(); parent != nil && parent.scheduler != nil && parent.scheduler.limiter == limiter {
+		return parent.scheduler.acquire(ctx, lc, n)
+	}
+	return waitN(ctx, limiter, n)
+}
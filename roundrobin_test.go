@@ -0,0 +1,66 @@
+package limitedlistener
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestRoundRobinScheduling verifies that a connection issuing four concurrent reads progresses at the same
+// pace as one issuing a single read when the global budget is scheduled round robin.
+func TestRoundRobinScheduling(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 20_000, 20_000, WithRoundRobinScheduling(1_000))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer listener.Close()
+
+	start := func(readers int) (*atomic.Int64, func()) {
+		server, client := net.Pipe()
+		lc := admitConn(t, listener, server)
+
+		go func() {
+			chunk := make([]byte, 1_000)
+			for {
+				if _, err := client.Write(chunk); err != nil {
+					return
+				}
+			}
+		}()
+
+		var received atomic.Int64
+		for i := 0; i < readers; i++ {
+			go func() {
+				buf := make([]byte, 4_000)
+				for {
+					n, err := lc.Read(buf)
+					received.Add(int64(n))
+					if err != nil {
+						return
+					}
+				}
+			}()
+		}
+		return &received, func() {
+			client.Close()
+			lc.Close()
+		}
+	}
+
+	listener.globalReadLimiter.ReserveN(time.Now(), 20_000)
+
+	greedy, stopGreedy := start(4)
+	defer stopGreedy()
+	modest, stopModest := start(1)
+	defer stopModest()
+
+	time.Sleep(200 * time.Millisecond)
+	g0, m0 := greedy.Load(), modest.Load()
+	time.Sleep(time.Second)
+
+	g, m := greedy.Load()-g0, modest.Load()-m0
+	if m == 0 || float64(g)/float64(m) > 1.25 || float64(g)/float64(m) < 0.8 {
+		t.Errorf("expected equal progress, got %d bytes for the greedy connection and %d for the modest one", g, m)
+	}
+}
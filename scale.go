@@ -0,0 +1,45 @@
+package limitedlistener
+
+import (
+	"math"
+)
+
+// ScaleLimits multiplies the global and per-connection limits by factor, for example 1.5 to grant 50% more
+// bandwidth or 0.5 to halve it. The scaled limits are rounded to the nearest integer and floored at 1 byte per
+// second; unlimited limits stay unlimited. The read-modify-write is serialized with SetLimits.
+//
+// It returns ErrLimitOutOfRange if factor is not a positive finite number, and ErrInvalidLimits if the scaled
+// global limit would be lower than the per-connection one. The limits are left unchanged on error.
+func (l *LimitedListener) ScaleLimits(factor float64) error {
+	if factor <= 0 || math.IsInf(factor, 0) || math.IsNaN(factor) {
+		return ErrLimitOutOfRange
+	}
+
+	l.setLimitsMu.Lock()
+	defer l.setLimitsMu.Unlock()
+
+	l.mu.RLock()
+	global := scaleLimit(limitOf(l.globalReadLimiter), factor)
+	perConn := l.perConnFor(global, scaleLimit(l.perConnBandwidthLimit, factor))
+	l.mu.RUnlock()
+
+	if global > 0 && global < perConn {
+		return ErrInvalidLimits
+	}
+
+	l.applyLimits(global, perConn)
+	return nil
+}
+
+// scaleLimit multiplies limit by factor, rounding to the nearest integer and clamping to [1, math.MaxInt].
+// A zero limit means unlimited and is returned as is.
+func scaleLimit(limit int, factor float64) int {
+	if limit == 0 {
+		return 0
+	}
+	scaled := math.Round(float64(limit) * factor)
+	if scaled >= math.MaxInt {
+		return math.MaxInt
+	}
+	return max(int(scaled), 1)
+}
@@ -0,0 +1,68 @@
+package limitedlistener
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestScaleLimits verifies scaling the limits up and down, including rounding and the floor at 1.
+func TestScaleLimits(t *testing.T) {
+	tests := []struct {
+		name            string
+		global, perConn int
+		factor          float64
+		wantGlobal      int
+		wantPerConn     int
+	}{
+		{"double", 1_000, 100, 2, 2_000, 200},
+		{"halve", 1_000, 100, 0.5, 500, 50},
+		{"round to nearest", 1_000, 3, 0.5, 500, 2},
+		{"floor at one", 1_000, 1, 0.1, 100, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener, err := NewLimitedListener(nil, tt.global, tt.perConn)
+			if err != nil {
+				t.Fatalf("didn't expect error but got one: %v", err)
+			}
+			lc := admitConn(t, listener, nil)
+			lc.perConnLimiter()
+
+			if err := listener.ScaleLimits(tt.factor); err != nil {
+				t.Fatalf("didn't expect error but got one: %v", err)
+			}
+
+			config := listener.ExportConfig()
+			if config.GlobalLimit != tt.wantGlobal || config.PerConnLimit != tt.wantPerConn {
+				t.Errorf("expected limits %d/%d, got %d/%d", tt.wantGlobal, tt.wantPerConn, config.GlobalLimit, config.PerConnLimit)
+			}
+			if got := limitOf(lc.Limiter()); got != tt.wantPerConn {
+				t.Errorf("expected the connection limit to be %d, got %d", tt.wantPerConn, got)
+			}
+		})
+	}
+}
+
+// TestScaleLimitsInvalid verifies that invalid factors are rejected and leave the limits unchanged.
+func TestScaleLimitsInvalid(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 1_000, 100)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	for _, factor := range []float64{0, -1, math.Inf(1), math.NaN()} {
+		if err := listener.ScaleLimits(factor); !errors.Is(err, ErrLimitOutOfRange) {
+			t.Errorf("expected ErrLimitOutOfRange for factor %v, got %v", factor, err)
+		}
+	}
+
+	listener.GlobalLimiter().SetLimit(10)
+	if err := listener.ScaleLimits(2); !errors.Is(err, ErrInvalidLimits) {
+		t.Errorf("expected ErrInvalidLimits when the global limit falls below the per-connection one, got %v", err)
+	}
+	if config := listener.ExportConfig(); config.GlobalLimit != 10 || config.PerConnLimit != 100 {
+		t.Errorf("expected the limits to be unchanged, got %+v", config)
+	}
+}
@@ -0,0 +1,100 @@
+package limitedlistener
+
+import (
+	"fmt"
+	"time"
+)
+
+var ErrScheduleOutOfRange = fmt.Errorf("schedule windows must start and end within a day")
+
+// day is the length of the cycle of a schedule.
+const day = 24 * time.Hour
+
+// ScheduledLimit sets the limits enforced during a daily time window. Start and End are offsets from midnight in
+// the clock's location, e.g. 9*time.Hour for 09:00; a window whose End is before its Start spans midnight and a
+// window whose Start equals its End lasts the whole day. Limits are in bytes per second.
+type ScheduledLimit struct {
+	Start   time.Duration
+	End     time.Duration
+	Global  int
+	PerConn int
+}
+
+// WithSchedule applies different limits depending on the time of day. A background goroutine switches the limits
+// at every window boundary. When windows overlap, the first matching entry wins; outside every window the limits
+// the listener was created with apply. Calls to SetLimits last until the next boundary.
+func WithSchedule(schedule []ScheduledLimit) Option {
+	return func(o *options) {
+		o.schedule = append([]ScheduledLimit(nil), schedule...)
+	}
+}
+
+// contains reports whether the window contains the offset from midnight d.
+func (s ScheduledLimit) contains(d time.Duration) bool {
+	switch {
+	case s.Start == s.End:
+		return true
+	case s.Start < s.End:
+		return d >= s.Start && d < s.End
+	default:
+		return d >= s.Start || d < s.End
+	}
+}
+
+// sinceMidnight returns the offset of t from the start of its day.
+func sinceMidnight(t time.Time) time.Duration {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return t.Sub(midnight)
+}
+
+// scheduledLimits returns the limits that apply at the offset from midnight d.
+func (l *LimitedListener) scheduledLimits(d time.Duration) (global, perConn int) {
+	for _, entry := range l.opts.schedule {
+		if entry.contains(d) {
+			return entry.Global, entry.PerConn
+		}
+	}
+	return l.opts.globalLimit, l.opts.perConnLimit
+}
+
+// nextBoundary returns how long after the offset from midnight d the next window starts or ends.
+func (l *LimitedListener) nextBoundary(d time.Duration) time.Duration {
+	next := day
+	for _, entry := range l.opts.schedule {
+		for _, boundary := range []time.Duration{entry.Start, entry.End} {
+			delta := boundary - d
+			if delta <= 0 {
+				delta += day
+			}
+			next = min(next, delta)
+		}
+	}
+	return next
+}
+
+// applySchedule enforces the limits of the schedule at now.
+func (l *LimitedListener) applySchedule(now time.Time) {
+	global, perConn := l.scheduledLimits(sinceMidnight(now))
+
+	l.setLimitsMu.Lock()
+	defer l.setLimitsMu.Unlock()
+
+	l.applyLimits(global, perConn)
+}
+
+// runSchedule applies the schedule at every window boundary after start until the listener is closed.
+// Boundaries are computed from the previous one rather than from the current time, so none is skipped
+// when the clock moves on before the goroutine starts waiting.
+func (l *LimitedListener) runSchedule(start time.Time) {
+	clock := l.opts.clock
+	at := start
+	for {
+		at = at.Add(l.nextBoundary(sinceMidnight(at)))
+		select {
+		case <-clock.After(at.Sub(clock.Now())):
+			l.applySchedule(clock.Now())
+		case <-l.done:
+			return
+		}
+	}
+}
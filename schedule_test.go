@@ -0,0 +1,120 @@
+package limitedlistener
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock that only moves when advanced.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward by d, firing the waiters that are due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+}
+
+// waitForLimits polls the listener until it enforces the given limits or a second has passed.
+func waitForLimits(t *testing.T, listener *LimitedListener, global, perConn int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		config := listener.ExportConfig()
+		if config.GlobalLimit == global && config.PerConnLimit == perConn {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected limits %d/%d, got %d/%d", global, perConn, config.GlobalLimit, config.PerConnLimit)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestSchedule verifies that the limits switch when the clock crosses the boundaries of a window.
+func TestSchedule(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 4, 8, 59, 0, 0, time.UTC)}
+	listener, err := NewLimitedListener(nil, 1_000, 100, WithClock(clock), WithSchedule([]ScheduledLimit{
+		{Start: 9 * time.Hour, End: 17 * time.Hour, Global: 2_000, PerConn: 200},
+		{Start: 12 * time.Hour, End: 13 * time.Hour, Global: 3_000, PerConn: 300},
+	}))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer listener.Close()
+
+	waitForLimits(t, listener, 1_000, 100)
+
+	clock.Advance(2 * time.Minute)
+	waitForLimits(t, listener, 2_000, 200)
+
+	// The overlapping lunch window is shadowed by the first entry.
+	clock.Advance(3 * time.Hour)
+	waitForLimits(t, listener, 2_000, 200)
+
+	clock.Advance(5 * time.Hour)
+	waitForLimits(t, listener, 1_000, 100)
+}
+
+// TestScheduleOverMidnight verifies windows spanning midnight and the validation of schedule entries.
+func TestScheduleOverMidnight(t *testing.T) {
+	night := ScheduledLimit{Start: 22 * time.Hour, End: 6 * time.Hour, Global: 5_000, PerConn: 500}
+	for _, tc := range []struct {
+		at   time.Duration
+		want bool
+	}{
+		{23 * time.Hour, true},
+		{time.Hour, true},
+		{6 * time.Hour, false},
+		{12 * time.Hour, false},
+	} {
+		if got := night.contains(tc.at); got != tc.want {
+			t.Errorf("expected contains(%v) to be %v", tc.at, tc.want)
+		}
+	}
+
+	_, err := NewLimitedListener(nil, 1_000, 100, WithSchedule([]ScheduledLimit{{Start: 25 * time.Hour, End: time.Hour, Global: 1, PerConn: 1}}))
+	if !errors.Is(err, ErrScheduleOutOfRange) {
+		t.Errorf("expected ErrScheduleOutOfRange, got %v", err)
+	}
+}
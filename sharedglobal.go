@@ -0,0 +1,42 @@
+package limitedlistener
+
+import "golang.org/x/time/rate"
+
+// WithSharedGlobalLimit makes writes wait on the global read limiter too, after the global write limiter, so
+// the total traffic of the listener in both directions is capped by the global limit set with WithLimits and
+// SetLimits. The global write limit, if any, still caps egress on its own. Writes are charged to the global limiter
+// directly and do not take part in the round-robin scheduling of WithRoundRobinScheduling.
+func WithSharedGlobalLimit() Option {
+	return func(o *options) {
+		o.sharedGlobalLimit = true
+	}
+}
+
+// writeLimiters appends the limiters a write waits on to dst, in order: the global write limiter, then the global
+// read limiter if it is shared with writes.
+func (lc *LimitedConnection) writeLimiters(dst []*rate.Limiter) []*rate.Limiter {
+	dst = append(dst, lc.globalWriteLimiter.Load())
+	if lc.options().sharedGlobalLimit {
+		dst = append(dst, lc.globalReadLimiter.Load())
+	}
+	return dst
+}
+
+// writesUnlimited reports whether none of the limiters writes wait on throttles.
+func (lc *LimitedConnection) writesUnlimited() bool {
+	var buf [2]*rate.Limiter
+	for _, limiter := range lc.writeLimiters(buf[:0]) {
+		if limiter.Limit() != rate.Inf {
+			return false
+		}
+	}
+	return true
+}
+
+// writeLimiterName names the limiter at position i of the chain returned by writeLimiters, for error messages.
+func writeLimiterName(i int) string {
+	if i == 1 {
+		return "global"
+	}
+	return "global write"
+}
@@ -0,0 +1,58 @@
+package limitedlistener
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestSharedGlobalLimit verifies that the combined egress of several connections stays under the global limit
+// when writes share it, and that SetLimits applies to writes as well.
+func TestSharedGlobalLimit(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 4_000, 4_000, WithSharedGlobalLimit())
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	writeAll := func(conns int, size int) time.Duration {
+		var wg sync.WaitGroup
+		start := time.Now()
+		for i := 0; i < conns; i++ {
+			server, client := net.Pipe()
+			lc := listener.Track(server)
+			go func() {
+				buf := make([]byte, 1_000)
+				for {
+					if _, err := client.Read(buf); err != nil {
+						return
+					}
+				}
+			}()
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer client.Close()
+				defer lc.Close()
+				if _, err := lc.Write(make([]byte, size)); err != nil {
+					t.Errorf("didn't expect error but got one: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+		return time.Since(start)
+	}
+
+	// 3 x 2000 bytes at 4000 bytes per second, with a burst of 4000: at least 500ms.
+	if elapsed := writeAll(3, 2_000); elapsed < 450*time.Millisecond {
+		t.Errorf("expected the combined egress to be capped by the global limit, took %v", elapsed)
+	}
+
+	listener.SetLimits(2_000, 2_000)
+	listener.globalReadLimiter.AllowN(time.Now(), 2_000)
+	// 3 x 1000 bytes at 2000 bytes per second from an empty bucket: at least 1.5s.
+	if elapsed := writeAll(3, 1_000); elapsed < 1_400*time.Millisecond {
+		t.Errorf("expected writes to follow the global limit set with SetLimits, took %v", elapsed)
+	}
+}
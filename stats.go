@@ -0,0 +1,146 @@
+package limitedlistener
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Stats is a point-in-time view of a listener's counters and limits. It marshals to JSON with stable field names.
+// Byte counters are int64 and saturate at math.MaxInt64 instead of wrapping around to negative values.
+type Stats struct {
+	ActiveConnections   int   `json:"active_connections"`
+	AcceptedConnections int64 `json:"accepted_connections"`
+	ClosedConnections   int64 `json:"closed_connections"`
+	TotalBytes          int64 `json:"total_bytes"`
+	GlobalLimit         int   `json:"global_limit"`
+	PerConnLimit        int   `json:"per_conn_limit"`
+	GlobalWriteLimit    int   `json:"global_write_limit"`
+}
+
+// ConnSnapshot is a point-in-time view of a single connection. It marshals to JSON with stable field names;
+// durations are rendered as integer nanoseconds.
+type ConnSnapshot struct {
+	ID                  uint64        `json:"id"`
+	RemoteAddr          string        `json:"remote_addr"`
+	LocalListenerAddr   string        `json:"local_listener_addr"`
+	BytesRead           int64         `json:"bytes_read"`
+	BytesRequested      int64         `json:"bytes_requested"`
+	BytesWritten        int64         `json:"bytes_written"`
+	LogicalBytesWritten int64         `json:"logical_bytes_written"`
+	PerConnLimit        int           `json:"per_conn_limit"`
+	WaitTime            time.Duration `json:"wait_time_ns"`
+}
+
+// Stats returns the current counters and limits of the listener. Limits of zero mean unlimited.
+func (l *LimitedListener) Stats() Stats {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return Stats{
+		ActiveConnections:   len(l.connections),
+		AcceptedConnections: l.accepted.Load(),
+		ClosedConnections:   l.closed.Load(),
+		TotalBytes:          l.totalBytes.Load(),
+		GlobalLimit:         limitOf(l.globalReadLimiter),
+		PerConnLimit:        l.perConnBandwidthLimit,
+		GlobalWriteLimit:    limitOf(l.globalWriteLimiter),
+	}
+}
+
+// StatsDelta is the change of a listener's counters since an earlier Stats, along with the current value of its
+// gauges. Current holds the Stats the delta was computed from, to pass to the next StatsSince call.
+type StatsDelta struct {
+	AcceptedConnections int64 `json:"accepted_connections"`
+	ClosedConnections   int64 `json:"closed_connections"`
+	TotalBytes          int64 `json:"total_bytes"`
+	ActiveConnections   int   `json:"active_connections"`
+	GlobalLimit         int   `json:"global_limit"`
+	PerConnLimit        int   `json:"per_conn_limit"`
+	GlobalWriteLimit    int   `json:"global_write_limit"`
+	Current             Stats `json:"current"`
+}
+
+// StatsSince returns what changed since prev, typically the Current field of the previous delta, so that a
+// high-frequency poller only processes deltas. Counters lower than in prev, for example because prev was taken
+// from a listener that has since been replaced, are treated as reset and counted from zero.
+func (l *LimitedListener) StatsSince(prev Stats) StatsDelta {
+	current := l.Stats()
+	return StatsDelta{
+		AcceptedConnections: counterDelta(prev.AcceptedConnections, current.AcceptedConnections),
+		ClosedConnections:   counterDelta(prev.ClosedConnections, current.ClosedConnections),
+		TotalBytes:          counterDelta(prev.TotalBytes, current.TotalBytes),
+		ActiveConnections:   current.ActiveConnections,
+		GlobalLimit:         current.GlobalLimit,
+		PerConnLimit:        current.PerConnLimit,
+		GlobalWriteLimit:    current.GlobalWriteLimit,
+		Current:             current,
+	}
+}
+
+// counterDelta returns how much a counter grew from prev to current, or current if it was reset in between.
+func counterDelta(prev, current int64) int64 {
+	if current < prev {
+		return current
+	}
+	return current - prev
+}
+
+// Connections returns a snapshot of every connection tracked by the listener.
+func (l *LimitedListener) Connections() []ConnSnapshot {
+	connections := l.trackedConnections()
+	snapshots := make([]ConnSnapshot, 0, len(connections))
+	for _, connection := range connections {
+		snapshots = append(snapshots, connection.Snapshot())
+	}
+	return snapshots
+}
+
+// Snapshot returns the current counters and limit of the connection.
+func (lc *LimitedConnection) Snapshot() ConnSnapshot {
+	lc.mu.Lock()
+	perConnLimit := lc.bytesPerSecond
+	lc.mu.Unlock()
+
+	snapshot := ConnSnapshot{
+		ID:                  lc.id,
+		BytesRead:           lc.bytesRead.Load(),
+		BytesRequested:      lc.bytesRequested.Load(),
+		BytesWritten:        lc.bytesWritten.Load(),
+		LogicalBytesWritten: lc.logicalBytesWritten.Load(),
+		PerConnLimit:        perConnLimit,
+		WaitTime:            time.Duration(lc.waitTime.Load()),
+	}
+	if addr := lc.RemoteAddr(); addr != nil {
+		snapshot.RemoteAddr = addr.String()
+	}
+	if addr := lc.listenerAddr; addr != nil {
+		snapshot.LocalListenerAddr = addr.String()
+	}
+	return snapshot
+}
+
+// addSaturating adds n to counter, clamping the result at math.MaxInt64 instead of wrapping around.
+// At 100 Gbit/s an int64 byte counter takes more than 20 years to saturate.
+func addSaturating(counter *atomic.Int64, n int64) int64 {
+	for {
+		current := counter.Load()
+		next := current + n
+		if next < current {
+			next = math.MaxInt64
+		}
+		if counter.CompareAndSwap(current, next) {
+			return next
+		}
+	}
+}
+
+// limitOf returns the limit of the limiter in bytes per second, or zero if it is unlimited.
+func limitOf(limiter *rate.Limiter) int {
+	if limit := limiter.Limit(); limit != rate.Inf {
+		return int(limit)
+	}
+	return 0
+}
@@ -0,0 +1,222 @@
+package limitedlistener
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestStats verifies that the listener stats and connection snapshots reflect a transfer.
+func TestStats(t *testing.T) {
+	limitedListener, err := Listen("tcp", "127.0.0.1:0", WithLimits(1_000_000, 500_000))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer limitedListener.Close()
+
+	client, err := net.Dial("tcp", limitedListener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	defer client.Close()
+
+	conn, err := limitedListener.Accept()
+	if err != nil {
+		t.Fatalf("accept error: %v", err)
+	}
+
+	go client.Write(make([]byte, 1_000))
+	if _, err := io.ReadFull(conn, make([]byte, 1_000)); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+
+	snapshots := limitedListener.Connections()
+	if len(snapshots) != 1 {
+		t.Fatalf("expected 1 snapshot but got %d", len(snapshots))
+	}
+	if got := snapshots[0]; got.BytesRead != 1_000 || got.PerConnLimit != 500_000 || got.RemoteAddr != client.LocalAddr().String() {
+		t.Errorf("unexpected snapshot %+v", got)
+	}
+
+	conn.Close()
+
+	want := Stats{
+		AcceptedConnections: 1,
+		ClosedConnections:   1,
+		TotalBytes:          1_000,
+		GlobalLimit:         1_000_000,
+		PerConnLimit:        500_000,
+	}
+	if got := limitedListener.Stats(); got != want {
+		t.Errorf("expected %+v, but got %+v", want, got)
+	}
+}
+
+// TestStatsJSON verifies that the JSON shape of Stats and ConnSnapshot is stable.
+func TestStatsJSON(t *testing.T) {
+	stats := Stats{
+		ActiveConnections:   2,
+		AcceptedConnections: 5,
+		ClosedConnections:   3,
+		TotalBytes:          1024,
+		GlobalLimit:         1000,
+		PerConnLimit:        100,
+		GlobalWriteLimit:    0,
+	}
+	snapshot := ConnSnapshot{
+		ID:                  7,
+		RemoteAddr:          "127.0.0.1:1234",
+		LocalListenerAddr:   "127.0.0.1:8080",
+		BytesRead:           10,
+		BytesRequested:      40,
+		BytesWritten:        20,
+		LogicalBytesWritten: 80,
+		PerConnLimit:        100,
+		WaitTime:            1500 * time.Millisecond,
+	}
+
+	testCases := []struct {
+		test  string
+		value any
+		want  string
+	}{
+		{
+			"Stats",
+			stats,
+			`{"active_connections":2,"accepted_connections":5,"closed_connections":3,"total_bytes":1024,"global_limit":1000,"per_conn_limit":100,"global_write_limit":0}`,
+		},
+		{
+			"ConnSnapshot",
+			snapshot,
+			`{"id":7,"remote_addr":"127.0.0.1:1234","local_listener_addr":"127.0.0.1:8080","bytes_read":10,"bytes_requested":40,"bytes_written":20,"logical_bytes_written":80,"per_conn_limit":100,"wait_time_ns":1500000000}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.test, func(t *testing.T) {
+			got, err := json.Marshal(tc.value)
+			if err != nil {
+				t.Fatalf("didn't expect error but got one: %v", err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("expected %s, but got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+// TestCountersDoNotOverflow verifies that large transfers keep the byte counters correct and never negative.
+func TestCountersDoNotOverflow(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 100, 100)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, nil)
+
+	const terabyte = 1 << 40
+	for i := 0; i < 4; i++ {
+		lc.recordRead(terabyte)
+	}
+	if got := lc.BytesRead(); got != 4*terabyte {
+		t.Errorf("expected %d bytes read, but got %d", int64(4*terabyte), got)
+	}
+
+	lc.bytesRead.Store(math.MaxInt64 - 10)
+	lc.recordRead(terabyte)
+	if got := lc.BytesRead(); got != math.MaxInt64 {
+		t.Errorf("expected the counter to saturate at %d, but got %d", int64(math.MaxInt64), got)
+	}
+
+	listener.totalBytes.Store(math.MaxInt64 - 10)
+	lc.recordWritten(terabyte)
+	if got := listener.TotalBytes(); got < 0 {
+		t.Errorf("expected the total to stay non-negative, but got %d", got)
+	}
+}
+
+// TestBytesRequested verifies that oversized buffers show up as a large gap between the requested and the read
+// bytes: reads are capped at the burst, so most of a 64 KiB buffer is never filled under a 1000 B/s limit.
+func TestBytesRequested(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 100_000, 1_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	go client.Write(make([]byte, 1_000))
+
+	buf := make([]byte, 64<<10)
+	if _, err := lc.Read(buf); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+
+	if requested, read := lc.BytesRequested(), lc.BytesRead(); requested != 64<<10 || read != 1_000 {
+		t.Errorf("expected 65536 bytes requested and 1000 read, got %d and %d", requested, read)
+	}
+	if snapshot := lc.Snapshot(); snapshot.BytesRequested != lc.BytesRequested() {
+		t.Errorf("expected the snapshot to report the requested bytes, got %d", snapshot.BytesRequested)
+	}
+}
+
+// TestStatsSince verifies the deltas computed across two transfers and after a counter reset.
+func TestStatsSince(t *testing.T) {
+	limitedListener, err := Listen("tcp", "127.0.0.1:0", WithLimits(1_000_000, 500_000))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer limitedListener.Close()
+
+	transfer := func(size int) {
+		t.Helper()
+		client, err := net.Dial("tcp", limitedListener.Addr().String())
+		if err != nil {
+			t.Fatalf("dial error: %v", err)
+		}
+		defer client.Close()
+
+		conn, err := limitedListener.Accept()
+		if err != nil {
+			t.Fatalf("accept error: %v", err)
+		}
+		defer conn.Close()
+
+		go client.Write(make([]byte, size))
+		if _, err := io.ReadFull(conn, make([]byte, size)); err != nil {
+			t.Fatalf("read error: %v", err)
+		}
+	}
+
+	transfer(1_000)
+	first := limitedListener.StatsSince(Stats{})
+	if first.TotalBytes != 1_000 || first.AcceptedConnections != 1 || first.ClosedConnections != 1 {
+		t.Errorf("unexpected first delta %+v", first)
+	}
+
+	transfer(2_500)
+	second := limitedListener.StatsSince(first.Current)
+	want := StatsDelta{
+		AcceptedConnections: 1,
+		ClosedConnections:   1,
+		TotalBytes:          2_500,
+		GlobalLimit:         1_000_000,
+		PerConnLimit:        500_000,
+		Current:             limitedListener.Stats(),
+	}
+	if second != want {
+		t.Errorf("expected %+v, but got %+v", want, second)
+	}
+
+	reset := limitedListener.StatsSince(Stats{TotalBytes: math.MaxInt64, AcceptedConnections: 100, ClosedConnections: 100})
+	if reset.TotalBytes != 3_500 || reset.AcceptedConnections != 2 || reset.ClosedConnections != 2 {
+		t.Errorf("expected counters lower than the previous stats to count from zero, got %+v", reset)
+	}
+}
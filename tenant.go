@@ -0,0 +1,131 @@
+package limitedlistener
+
+import (
+	"net"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// tenantGroup is the aggregate limiter shared by the connections of one tenant, with the number of
+// tracked connections referencing it.
+type tenantGroup struct {
+	limiter *rate.Limiter
+	refs    int
+}
+
+// WithTenant assigns every accepted connection to the tenant returned by tenantOf, for example derived from
+// its remote address or a TLS client certificate. All connections of a tenant share one aggregate limiter,
+// set with SetTenantLimit, on top of the global and per-connection limits. An empty tenant means none.
+func WithTenant(tenantOf func(net.Conn) string) Option {
+	return func(o *options) {
+		o.tenantOf = tenantOf
+	}
+}
+
+// SetTenantLimit sets the aggregate bandwidth limit in bytes per second shared by all connections of tenant,
+// where zero means unlimited. It applies to the connections already open as well as to future ones; the limit of
+// a tenant without connections is kept until it connects again. Negative limits are ignored.
+//
+// Tenant limiters are created when the first connection of a tenant is accepted and dropped when its last
+// connection is closed.
+func (l *LimitedListener) SetTenantLimit(tenant string, bytesPerSecond int) {
+	if bytesPerSecond < 0 {
+		return
+	}
+
+	l.tenantMu.Lock()
+	defer l.tenantMu.Unlock()
+
+	if l.tenantLimits == nil {
+		l.tenantLimits = make(map[string]int)
+	}
+	l.tenantLimits[tenant] = bytesPerSecond
+	if group, ok := l.tenants[tenant]; ok {
+		setLimiterRate(group.limiter, bytesPerSecond)
+	}
+}
+
+// joinTenant attaches the aggregate limiter of tenant to lc, creating it on first use.
+// The caller must hold the listener lock, as for leaveTenant.
+func (l *LimitedListener) joinTenant(lc *LimitedConnection, tenant string) {
+	l.tenantMu.Lock()
+	defer l.tenantMu.Unlock()
+
+	group, ok := l.tenants[tenant]
+	if !ok {
+		if l.tenants == nil {
+			l.tenants = make(map[string]*tenantGroup)
+		}
+		group = &tenantGroup{limiter: newLimiter(l.tenantLimits[tenant])}
+		l.tenants[tenant] = group
+	}
+	group.refs++
+	lc.tenant = tenant
+	lc.AddLimiter(group.limiter)
+}
+
+// leaveTenant detaches the aggregate limiter of its tenant from lc and drops its reference, removing the tenant
+// limiter with the last one.
+func (l *LimitedListener) leaveTenant(lc *LimitedConnection) {
+	l.tenantMu.Lock()
+	defer l.tenantMu.Unlock()
+
+	group, ok := l.tenants[lc.tenant]
+	if !ok {
+		return
+	}
+	lc.removeLimiter(group.limiter)
+	if group.refs--; group.refs == 0 {
+		delete(l.tenants, lc.tenant)
+	}
+}
+
+// WithLimiterCompaction runs CompactLimiters every interval in the background until the listener is closed.
+func WithLimiterCompaction(interval time.Duration) Option {
+	return func(o *options) {
+		o.compactionInterval = interval
+	}
+}
+
+// CompactLimiters drops the tenant limiters no tracked connection uses anymore. The reference counts are
+// recomputed from the tracked connections rather than trusted, so entries leaked by a miscount are
+// reclaimed as well. It returns the number of limiters removed.
+func (l *LimitedListener) CompactLimiters() int {
+	// Connections join and leave tenants under the listener lock, so holding it keeps the counts consistent.
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	refs := make(map[string]int)
+	for connection := range l.connections {
+		if connection.tenant != "" {
+			refs[connection.tenant]++
+		}
+	}
+
+	l.tenantMu.Lock()
+	defer l.tenantMu.Unlock()
+
+	removed := 0
+	for tenant, group := range l.tenants {
+		if refs[tenant] == 0 {
+			delete(l.tenants, tenant)
+			removed++
+			continue
+		}
+		group.refs = refs[tenant]
+	}
+	return removed
+}
+
+// runLimiterCompaction calls CompactLimiters every interval until the listener is closed.
+func (l *LimitedListener) runLimiterCompaction() {
+	for {
+		select {
+		case <-l.opts.clock.After(l.opts.compactionInterval):
+			l.CompactLimiters()
+		case <-l.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package limitedlistener
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestTenantLimit verifies that each tenant is bounded by its aggregate limit regardless of how many
+// connections it opens, and that tenant limiters are dropped with their last connection.
+func TestTenantLimit(t *testing.T) {
+	tenantOf := make(map[net.Conn]string)
+	listener, err := NewLimitedListener(nil, 1_000_000, 1_000_000, WithTenant(func(conn net.Conn) string {
+		return tenantOf[conn]
+	}))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	listener.SetTenantLimit("a", 2_000)
+	listener.SetTenantLimit("b", 2_000)
+
+	received := map[string]*atomic.Int64{"a": new(atomic.Int64), "b": new(atomic.Int64)}
+	var conns []*LimitedConnection
+	for _, tenant := range []string{"a", "a", "a", "b"} {
+		server, client := net.Pipe()
+		defer client.Close()
+		tenantOf[server] = tenant
+
+		lc, err := listener.admit(server)
+		if err != nil {
+			t.Fatalf("didn't expect error but got one: %v", err)
+		}
+		conns = append(conns, lc)
+
+		go func() {
+			chunk := make([]byte, 500)
+			for {
+				if _, err := client.Write(chunk); err != nil {
+					return
+				}
+			}
+		}()
+		go func(counter *atomic.Int64) {
+			buf := make([]byte, 500)
+			for {
+				n, err := lc.Read(buf)
+				counter.Add(int64(n))
+				if err != nil {
+					return
+				}
+			}
+		}(received[tenant])
+	}
+
+	time.Sleep(time.Second)
+
+	for tenant, counter := range received {
+		// The burst of 2000 bytes plus one second at 2000 B/s.
+		if got := counter.Load(); got < 3_000 || got > 4_500 {
+			t.Errorf("expected tenant %s to transfer about 4000 bytes, got %d", tenant, got)
+		}
+	}
+
+	for _, lc := range conns {
+		lc.Close()
+	}
+	listener.tenantMu.Lock()
+	defer listener.tenantMu.Unlock()
+	if len(listener.tenants) != 0 {
+		t.Errorf("expected the tenant limiters to be dropped with their last connection, got %d", len(listener.tenants))
+	}
+}
+
+// TestCompactLimiters verifies that closing connections of many tenants shrinks the tenant map and that
+// compaction reclaims limiters leaked by a reference miscount.
+func TestCompactLimiters(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 1_000_000, 1_000_000, WithTenant(func(conn net.Conn) string {
+		return conn.(*tenantConn).tenant
+	}))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	var kept *LimitedConnection
+	for i := 0; i < 100; i++ {
+		server, client := net.Pipe()
+		client.Close()
+		lc, err := listener.admit(&tenantConn{Conn: server, tenant: fmt.Sprintf("10.0.0.%d", i)})
+		if err != nil {
+			t.Fatalf("didn't expect error but got one: %v", err)
+		}
+		if i == 0 {
+			kept = lc
+			continue
+		}
+		lc.Close()
+	}
+
+	// Simulate a miscount leaving a reference behind.
+	listener.tenantMu.Lock()
+	listener.tenants["10.0.0.0"].refs++
+	listener.tenants["leaked"] = &tenantGroup{limiter: newLimiter(0), refs: 1}
+	listener.tenantMu.Unlock()
+
+	if removed := listener.CompactLimiters(); removed != 1 {
+		t.Errorf("expected the leaked limiter to be removed, got %d removed", removed)
+	}
+
+	kept.Close()
+	listener.tenantMu.Lock()
+	defer listener.tenantMu.Unlock()
+	if len(listener.tenants) != 0 {
+		t.Errorf("expected the tenant map to be empty after the last connection closed, got %d entries", len(listener.tenants))
+	}
+}
+
+// tenantConn carries the tenant of a test connection.
+type tenantConn struct {
+	net.Conn
+	tenant string
+}
+
+// TestTenantRelease verifies that a released connection leaves the limiter of its tenant behind, so it isn't
+// throttled by it once adopted by another listener.
+func TestTenantRelease(t *testing.T) {
+	oldListener, err := NewLimitedListener(nil, 1_000_000, 1_000_000, WithTenant(func(net.Conn) string { return "a" }))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	oldListener.SetTenantLimit("a", 1_000)
+	newListener, err := NewLimitedListener(nil, 1_000_000, 1_000_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	server, client := net.Pipe()
+	defer client.Close()
+	lc, err := oldListener.admit(server)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer lc.Close()
+	if got := len(lc.limiters(nil)); got != 3 {
+		t.Fatalf("expected the tenant limiter in the chain, got %d limiters", got)
+	}
+
+	oldListener.Release(lc)
+	newListener.Adopt(lc)
+
+	if got := len(lc.limiters(nil)); got != 2 {
+		t.Errorf("expected the tenant limiter to be detached, got %d limiters", got)
+	}
+	if got := len(oldListener.tenants); got != 0 {
+		t.Errorf("expected the tenant limiter to be dropped with its last connection, got %d tenants", got)
+	}
+
+	go client.Write(make([]byte, 5_000))
+	start := time.Now()
+	if _, err := io.ReadFull(lc, make([]byte, 5_000)); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the old tenant limit not to apply, but the read took %v", elapsed)
+	}
+}
@@ -0,0 +1,65 @@
+package testutil
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+)
+
+// Listener is an in-memory net.Listener whose connections are created by Dial and run over a simulated Link.
+type Listener struct {
+	link    Link
+	addr    Addr
+	pending chan *Conn
+	dialed  atomic.Int64
+
+	closeOnce sync.Once
+	closing   chan struct{}
+}
+
+// NewListener creates an in-memory listener whose connections run over link.
+func NewListener(link Link) *Listener {
+	return &Listener{
+		link:    link,
+		addr:    Addr("listener"),
+		pending: make(chan *Conn),
+		closing: make(chan struct{}),
+	}
+}
+
+// Dial connects to the listener and returns the client end of the connection. It blocks until the connection is
+// accepted and returns net.ErrClosed if the listener is closed first.
+func (l *Listener) Dial() (net.Conn, error) {
+	client, server := pipe(l.link, Addr(fmt.Sprintf("client-%d", l.dialed.Add(1))), l.addr)
+	select {
+	case l.pending <- server:
+		return client, nil
+	case <-l.closing:
+		return nil, net.ErrClosed
+	}
+}
+
+// Accept waits for the next connection dialed with Dial and returns its server end.
+func (l *Listener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.pending:
+		return conn, nil
+	case <-l.closing:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close stops the listener. Blocked Accept and Dial calls return net.ErrClosed; connections already accepted are
+// not closed.
+func (l *Listener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.closing)
+	})
+	return nil
+}
+
+// Addr returns the address of the listener.
+func (l *Listener) Addr() net.Addr {
+	return l.addr
+}
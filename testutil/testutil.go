@@ -0,0 +1,273 @@
+// Package testutil provides an in-memory network transport for testing code layered over net.Conn and
+// net.Listener, such as the limited listener, without real sockets. The simulated link adds a fixed latency to
+// every write and carries at most a fixed bandwidth, so tests can check how a limiter behaves on a fast or a slow
+// underlying link.
+package testutil
+
+import (
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Link describes a simulated link. The zero value is a link with no latency and unlimited bandwidth.
+type Link struct {
+	// Latency is the one-way delay between the end of a write and the moment its data can be read.
+	Latency time.Duration
+	// Bandwidth is the number of bytes per second the link carries in each direction, or zero for no limit.
+	// Writes block while the link is busy transmitting, like a socket with a full send buffer.
+	Bandwidth int
+}
+
+// transmitTime returns how long the link takes to carry n bytes.
+func (l Link) transmitTime(n int) time.Duration {
+	if l.Bandwidth <= 0 {
+		return 0
+	}
+	return time.Duration(float64(n) / float64(l.Bandwidth) * float64(time.Second))
+}
+
+// Addr is the address of an in-memory connection or listener.
+type Addr string
+
+// Network returns "mem".
+func (Addr) Network() string {
+	return "mem"
+}
+
+func (a Addr) String() string {
+	return string(a)
+}
+
+// Pipe returns the two ends of an in-memory connection over link. Data written on one end can be read on the
+// other once it has been transmitted and the latency has passed.
+func Pipe(link Link) (net.Conn, net.Conn) {
+	return pipe(link, Addr("client"), Addr("server"))
+}
+
+func pipe(link Link, clientAddr, serverAddr Addr) (*Conn, *Conn) {
+	toServer, toClient := newHalf(link), newHalf(link)
+	return newConn(toClient, toServer, clientAddr, serverAddr), newConn(toServer, toClient, serverAddr, clientAddr)
+}
+
+func newConn(in, out *half, local, remote Addr) *Conn {
+	return &Conn{in: in, out: out, local: local, remote: remote, closing: make(chan struct{}), deadlineSet: make(chan struct{})}
+}
+
+// segment is the data of a single write, readable from readyAt.
+type segment struct {
+	data    []byte
+	readyAt time.Time
+}
+
+// half is one direction of a connection.
+type half struct {
+	link Link
+
+	mu        sync.Mutex
+	segments  []segment
+	busyUntil time.Time
+	closed    bool
+	changed   chan struct{}
+}
+
+func newHalf(link Link) *half {
+	return &half{link: link, changed: make(chan struct{})}
+}
+
+// notify wakes the goroutines waiting on h. The caller must hold h.mu.
+func (h *half) notify() {
+	close(h.changed)
+	h.changed = make(chan struct{})
+}
+
+func (h *half) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.closed {
+		h.closed = true
+		h.notify()
+	}
+}
+
+// Conn is one end of an in-memory connection created by Pipe or Listener.Dial.
+type Conn struct {
+	in, out       *half
+	local, remote Addr
+	closing       chan struct{}
+	closeOnce     sync.Once
+
+	// mu guards the deadlines. deadlineSet is closed and replaced whenever one of them changes, to wake the
+	// blocked operations.
+	mu            sync.Mutex
+	readDeadline  time.Time
+	writeDeadline time.Time
+	deadlineSet   chan struct{}
+}
+
+// Read reads the data of the oldest write that has arrived, waiting for it if none has. It returns io.EOF once
+// the peer is closed and every write has been read.
+func (c *Conn) Read(b []byte) (int, error) {
+	for {
+		select {
+		case <-c.closing:
+			return 0, net.ErrClosed
+		default:
+		}
+
+		c.in.mu.Lock()
+		now := time.Now()
+		if len(c.in.segments) > 0 && !c.in.segments[0].readyAt.After(now) {
+			first := &c.in.segments[0]
+			n := copy(b, first.data)
+			first.data = first.data[n:]
+			if len(first.data) == 0 {
+				c.in.segments = c.in.segments[1:]
+			}
+			c.in.mu.Unlock()
+			return n, nil
+		}
+		if len(c.in.segments) == 0 && c.in.closed {
+			c.in.mu.Unlock()
+			return 0, io.EOF
+		}
+		wait := time.Duration(-1)
+		if len(c.in.segments) > 0 {
+			wait = c.in.segments[0].readyAt.Sub(now)
+		}
+		changed := c.in.changed
+		c.in.mu.Unlock()
+
+		if err := c.wait(wait, changed, &c.readDeadline); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Write queues b for the peer. It blocks while the link is transmitting, so the writer is held to the bandwidth
+// of the link, and returns once b has been transmitted; the peer can read it after the latency.
+func (c *Conn) Write(b []byte) (int, error) {
+	select {
+	case <-c.closing:
+		return 0, net.ErrClosed
+	default:
+	}
+
+	c.out.mu.Lock()
+	if c.out.closed {
+		c.out.mu.Unlock()
+		return 0, io.ErrClosedPipe
+	}
+	now := time.Now()
+	if c.out.busyUntil.Before(now) {
+		c.out.busyUntil = now
+	}
+	c.out.busyUntil = c.out.busyUntil.Add(c.out.link.transmitTime(len(b)))
+	sent := c.out.busyUntil
+	c.out.segments = append(c.out.segments, segment{data: append([]byte(nil), b...), readyAt: sent.Add(c.out.link.Latency)})
+	c.out.notify()
+	c.out.mu.Unlock()
+
+	// The data is queued as soon as the write starts, like bytes handed to a socket, so a write cut off by its
+	// deadline reports an error even though the peer may still read the data.
+	for now := time.Now(); now.Before(sent); now = time.Now() {
+		if err := c.wait(sent.Sub(now), nil, &c.writeDeadline); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// wait blocks until d has passed, with a negative d meaning forever, changed is closed, the connection is closed
+// or the deadline stored in deadline changes or passes. It returns os.ErrDeadlineExceeded once the deadline has
+// passed and net.ErrClosed once the connection is closed.
+func (c *Conn) wait(d time.Duration, changed <-chan struct{}, deadline *time.Time) error {
+	c.mu.Lock()
+	until, set := *deadline, c.deadlineSet
+	c.mu.Unlock()
+
+	select {
+	case <-c.closing:
+		return net.ErrClosed
+	default:
+	}
+	if !until.IsZero() {
+		left := time.Until(until)
+		if left <= 0 {
+			return os.ErrDeadlineExceeded
+		}
+		if d < 0 || left < d {
+			d = left
+		}
+	}
+
+	var timeout <-chan time.Time
+	if d >= 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-timeout:
+	case <-changed:
+	case <-set:
+	case <-c.closing:
+		return net.ErrClosed
+	}
+	if !until.IsZero() && !time.Now().Before(until) {
+		return os.ErrDeadlineExceeded
+	}
+	return nil
+}
+
+// Close closes the connection. Blocked reads and writes return net.ErrClosed; the peer reads the data already
+// written and then io.EOF.
+func (c *Conn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closing)
+		c.out.close()
+		c.in.close()
+	})
+	return nil
+}
+
+// LocalAddr returns the address of this end of the connection.
+func (c *Conn) LocalAddr() net.Addr {
+	return c.local
+}
+
+// RemoteAddr returns the address of the other end of the connection.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+// SetDeadline sets the read and write deadlines.
+func (c *Conn) SetDeadline(t time.Time) error {
+	c.setDeadline(&c.readDeadline, t)
+	c.setDeadline(&c.writeDeadline, t)
+	return nil
+}
+
+// SetReadDeadline sets the deadline of Read calls, including the ones already blocked.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	c.setDeadline(&c.readDeadline, t)
+	return nil
+}
+
+// SetWriteDeadline sets the deadline of Write calls, including the ones already blocked.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	c.setDeadline(&c.writeDeadline, t)
+	return nil
+}
+
+func (c *Conn) setDeadline(dst *time.Time, t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	*dst = t
+	close(c.deadlineSet)
+	c.deadlineSet = make(chan struct{})
+}
@@ -0,0 +1,109 @@
+package testutil
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestLatency verifies that written data becomes readable after the latency of the link.
+func TestLatency(t *testing.T) {
+	client, server := Pipe(Link{Latency: 100 * time.Millisecond})
+	defer client.Close()
+	defer server.Close()
+
+	start := time.Now()
+	if _, err := client.Write([]byte("ping")); err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(server, buf); err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond || elapsed > 300*time.Millisecond {
+		t.Errorf("expected the data to arrive after about 100ms, took %v", elapsed)
+	}
+	if string(buf) != "ping" {
+		t.Errorf("expected ping, got %q", buf)
+	}
+}
+
+// TestBandwidth verifies that the link carries no more than its bandwidth.
+func TestBandwidth(t *testing.T) {
+	client, server := Pipe(Link{Bandwidth: 10_000})
+	defer client.Close()
+	defer server.Close()
+
+	go func() {
+		for i := 0; i < 5; i++ {
+			client.Write(make([]byte, 1_000))
+		}
+	}()
+
+	start := time.Now()
+	if _, err := io.ReadFull(server, make([]byte, 5_000)); err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 450*time.Millisecond || elapsed > 800*time.Millisecond {
+		t.Errorf("expected 5000 bytes at 10000 bytes per second to take about 500ms, took %v", elapsed)
+	}
+}
+
+// TestCloseAndDeadlines verifies the EOF after a close and the deadlines of blocked reads.
+func TestCloseAndDeadlines(t *testing.T) {
+	client, server := Pipe(Link{})
+	defer server.Close()
+
+	server.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if _, err := server.Read(make([]byte, 1)); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("expected os.ErrDeadlineExceeded, got %v", err)
+	}
+	server.SetReadDeadline(time.Time{})
+
+	client.Write([]byte("x"))
+	client.Close()
+	buf := make([]byte, 2)
+	if n, err := server.Read(buf); n != 1 || err != nil {
+		t.Errorf("expected to read the data written before the close, got %d, %v", n, err)
+	}
+	if _, err := server.Read(buf); err != io.EOF {
+		t.Errorf("expected io.EOF after the peer closed, got %v", err)
+	}
+	if _, err := client.Write([]byte("x")); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("expected net.ErrClosed writing on a closed connection, got %v", err)
+	}
+}
+
+// TestListener verifies that dialed connections are accepted and that Close stops the listener.
+func TestListener(t *testing.T) {
+	listener := NewListener(Link{})
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			t.Errorf("didn't expect error but got one: %v", err)
+		}
+		accepted <- conn
+	}()
+
+	client, err := listener.Dial()
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	server := <-accepted
+	if server.RemoteAddr() != client.LocalAddr() || server.LocalAddr() != listener.Addr() {
+		t.Errorf("expected the ends to agree on their addresses")
+	}
+
+	listener.Close()
+	if _, err := listener.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("expected net.ErrClosed after Close, got %v", err)
+	}
+	if _, err := listener.Dial(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("expected net.ErrClosed dialing a closed listener, got %v", err)
+	}
+}
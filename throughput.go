@@ -0,0 +1,92 @@
+package limitedlistener
+
+import (
+	"sync"
+	"time"
+)
+
+// WithThroughputSampling keeps the last samples throughput samples of every connection, taken every interval,
+// so recent throughput can be graphed without an external metrics system. Memory per connection is bounded by samples.
+func WithThroughputSampling(interval time.Duration, samples int) Option {
+	return func(o *options) {
+		o.sampleInterval = interval
+		o.samples = samples
+	}
+}
+
+// RecentThroughput returns the recent throughput samples of the connection in bytes per second, oldest first.
+// It returns nil unless the listener was created with WithThroughputSampling.
+func (lc *LimitedConnection) RecentThroughput() []float64 {
+	if lc.throughput == nil {
+		return nil
+	}
+	return lc.throughput.values()
+}
+
+// sampleThroughput pushes a throughput sample for the listener and every tracked connection each interval until the
+// listener is closed. The read throughput is sampled separately for RecommendLimits.
+func (l *LimitedListener) sampleThroughput() {
+	ticker := time.NewTicker(l.opts.sampleInterval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-l.done:
+			return
+		case now := <-ticker.C:
+			l.sampleThroughputOnce(now.Sub(last).Seconds())
+			last = now
+		}
+	}
+}
+
+// sampleThroughputOnce pushes one throughput sample, over elapsed seconds, for the listener and every tracked connection.
+func (l *LimitedListener) sampleThroughputOnce(elapsed float64) {
+	l.throughput.sample(l.totalBytes.Load(), elapsed)
+	l.readThroughput.sample(l.bytesRead.Load(), elapsed)
+	for _, connection := range l.trackedConnections() {
+		if connection.throughput != nil {
+			bytesRead := connection.bytesRead.Load()
+			connection.throughput.sample(bytesRead+connection.bytesWritten.Load(), elapsed)
+			connection.readThroughput.sample(bytesRead, elapsed)
+		}
+	}
+}
+
+// throughputRing is a fixed-size circular buffer of throughput samples.
+type throughputRing struct {
+	mu        sync.Mutex
+	samples   []float64
+	next      int
+	count     int
+	lastBytes int64
+}
+
+func newThroughputRing(size int) *throughputRing {
+	return &throughputRing{samples: make([]float64, size)}
+}
+
+// sample records the throughput since the previous sample, given the connection's byte counter and the elapsed seconds.
+func (r *throughputRing) sample(totalBytes int64, elapsed float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.samples[r.next] = float64(totalBytes-r.lastBytes) / elapsed
+	r.lastBytes = totalBytes
+	r.next = (r.next + 1) % len(r.samples)
+	r.count = min(r.count+1, len(r.samples))
+}
+
+// values returns a copy of the samples, oldest first.
+func (r *throughputRing) values() []float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	values := make([]float64, 0, r.count)
+	start := (r.next - r.count + len(r.samples)) % len(r.samples)
+	for i := 0; i < r.count; i++ {
+		values = append(values, r.samples[(start+i)%len(r.samples)])
+	}
+	return values
+}
@@ -0,0 +1,99 @@
+package limitedlistener
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestRecentThroughput verifies that the throughput samples of a connection reflect the bytes it transferred in
+// each interval. The sampler is driven by the test, so the sample boundaries don't depend on timing.
+func TestRecentThroughput(t *testing.T) {
+	// The interval is long enough for the background sampler never to run.
+	listener, err := NewLimitedListener(nil, 1_000_000, 1_000_000, WithThroughputSampling(time.Hour, 4))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer listener.Close()
+
+	server, client := net.Pipe()
+	defer client.Close()
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	go func() {
+		buf := make([]byte, 1_000)
+		for {
+			if _, err := client.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+
+	transfers := []struct{ read, written int }{{1_000, 0}, {0, 500}, {2_000, 2_000}, {0, 0}, {4_000, 0}}
+	for _, transfer := range transfers {
+		go client.Write(make([]byte, transfer.read))
+		if _, err := io.ReadFull(lc, make([]byte, transfer.read)); err != nil {
+			t.Fatalf("read error: %v", err)
+		}
+		if _, err := lc.Write(make([]byte, transfer.written)); err != nil {
+			t.Fatalf("write error: %v", err)
+		}
+		listener.sampleThroughputOnce(0.5)
+	}
+
+	// Only the last 4 samples are kept, at twice the bytes transferred in each half-second interval.
+	want := []float64{1_000, 8_000, 0, 8_000}
+	got := lc.RecentThroughput()
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, but got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected %v, but got %v", want, got)
+			break
+		}
+	}
+}
+
+// TestThroughputRing verifies that the ring keeps only the most recent samples, oldest first.
+func TestThroughputRing(t *testing.T) {
+	ring := newThroughputRing(3)
+	for i := int64(1); i <= 5; i++ {
+		ring.sample(i*10, 1)
+	}
+
+	ring.sample(80, 1)
+
+	got := ring.values()
+	want := []float64{10, 10, 30}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("expected %v, but got %v", want, got)
+	}
+
+	ring = newThroughputRing(3)
+	ring.sample(10, 1)
+	ring.sample(30, 1)
+	if got := ring.values(); len(got) != 2 || got[0] != 10 || got[1] != 20 {
+		t.Errorf("expected [10 20], but got %v", got)
+	}
+}
+
+// TestThroughputSamplingValidation verifies that a non-positive interval or sample count is rejected.
+func TestThroughputSamplingValidation(t *testing.T) {
+	for _, tc := range []struct {
+		interval time.Duration
+		samples  int
+	}{
+		{0, 4},
+		{-time.Second, 4},
+		{time.Second, 0},
+		{time.Second, -1},
+	} {
+		if _, err := NewLimitedListener(nil, 1_000, 100, WithThroughputSampling(tc.interval, tc.samples)); !errors.Is(err, ErrLimitOutOfRange) {
+			t.Errorf("expected ErrLimitOutOfRange for an interval of %v and %d samples, got %v", tc.interval, tc.samples, err)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package limitedlistener
+
+// WithTokenCost sets how many tokens transferring a given number of bytes costs, for reads and writes alike.
+// By default every byte costs one token; a cost function can instead charge a minimum per call to discourage
+// tiny reads, or grow non-linearly with the size. The read overhead set with WithReadOverhead is added on top.
+// The counters and the byte quota still only account the bytes.
+//
+// cost must not decrease as the size grows. Transfers are sized so that their cost fits in the burst of every
+// limiter; if a single byte already costs more than a burst, transfers are shrunk to a single byte and the charge
+// is split over several waits.
+func WithTokenCost(cost func(bytes int) int) Option {
+	return func(o *options) {
+		o.tokenCost = cost
+	}
+}
+
+// tokens returns the tokens charged for transferring n bytes.
+func (o *options) tokens(n int) int {
+	if o.tokenCost == nil {
+		return n
+	}
+	return max(o.tokenCost(n), 0)
+}
+
+// readTokens returns the tokens charged for reading n bytes, including the read overhead.
+func (o *options) readTokens(n int) int {
+	return o.tokens(n) + o.readOverhead
+}
+
+// fitRead returns the largest read size up to n whose charge is at most limit tokens, or 0 if there is none.
+func (o *options) fitRead(limit, n int) int {
+	if o.tokenCost == nil {
+		return min(max(limit-o.readOverhead, 0), n)
+	}
+	return fitCost(limit, n, o.readTokens)
+}
+
+// fitWrite returns the largest write size up to n whose charge is at most limit tokens, or 0 if there is none.
+func (o *options) fitWrite(limit, n int) int {
+	if o.tokenCost == nil {
+		return min(max(limit, 0), n)
+	}
+	return fitCost(limit, n, o.tokens)
+}
+
+// fitCost returns the largest size up to n whose cost is at most limit, or 0 if a single byte costs more.
+// The size is searched by doubling and then bisecting, so cost is only called with sizes up to twice the result.
+func fitCost(limit, n int, cost func(int) int) int {
+	if n < 1 || cost(1) > limit {
+		return 0
+	}
+	lo := 1
+	for lo < n {
+		next := n
+		if lo <= n/2 {
+			next = lo * 2
+		}
+		if cost(next) > limit {
+			hi := next
+			for hi-lo > 1 {
+				mid := lo + (hi-lo)/2
+				if cost(mid) <= limit {
+					lo = mid
+				} else {
+					hi = mid
+				}
+			}
+			return lo
+		}
+		lo = next
+	}
+	return lo
+}
@@ -0,0 +1,125 @@
+package limitedlistener
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// TestTokenCost verifies that a linear cost keeps the default rate and a minimum cost per read lowers it.
+func TestTokenCost(t *testing.T) {
+	measure := func(opts ...Option) time.Duration {
+		server, client := net.Pipe()
+		defer client.Close()
+
+		listener, err := NewLimitedListener(nil, 20_000, 20_000, opts...)
+		if err != nil {
+			t.Fatalf("didn't expect error but got one: %v", err)
+		}
+
+		lc := admitConn(t, listener, server)
+		defer lc.Close()
+
+		now := time.Now()
+		listener.globalReadLimiter.ReserveN(now, 20_000)
+		lc.Limiter().ReserveN(now, 20_000)
+
+		go client.Write(make([]byte, 10_000))
+
+		start := time.Now()
+		buf := make([]byte, 100)
+		for received := 0; received < 10_000; {
+			n, err := lc.Read(buf)
+			if err != nil {
+				t.Fatalf("read error: %v", err)
+			}
+			received += n
+		}
+		return time.Since(start)
+	}
+
+	linear := measure(WithTokenCost(func(bytes int) int { return bytes }))
+	minCost := measure(WithTokenCost(func(bytes int) int { return max(bytes, 200) }))
+
+	if linear < 400*time.Millisecond || linear > 700*time.Millisecond {
+		t.Errorf("expected 10k bytes at 20k/s to take about 500ms, took %v", linear)
+	}
+	if minCost < 900*time.Millisecond || minCost > 1300*time.Millisecond {
+		t.Errorf("expected a 200-token minimum per 100-byte read to halve the payload rate, took %v", minCost)
+	}
+}
+
+// TestTokenCostWrite verifies that writes are charged with the cost function too.
+func TestTokenCostWrite(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+	go func() {
+		buf := make([]byte, 1_000)
+		for {
+			if _, err := client.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+
+	listener, err := NewLimitedListener(nil, 20_000, 20_000, WithGlobalWriteLimit(20_000),
+		WithTokenCost(func(bytes int) int { return max(bytes, 200) }))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+	listener.globalWriteLimiter.ReserveN(time.Now(), 20_000)
+
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		if _, err := lc.Write(make([]byte, 100)); err != nil {
+			t.Fatalf("write error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond || elapsed > 1300*time.Millisecond {
+		t.Errorf("expected 100 writes costing 200 tokens each at 20k/s to take about 1s, took %v", elapsed)
+	}
+}
+
+// TestTokenCostFitsBurst verifies that reads with a non-linear cost are shrunk until their cost fits the burst.
+func TestTokenCostFitsBurst(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 1_000, 1_000, WithTokenCost(func(bytes int) int { return bytes * bytes }))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	go client.Write(make([]byte, 100))
+
+	n, err := lc.Read(make([]byte, 100))
+	if n != 31 || err != nil {
+		t.Errorf("expected the 31 bytes whose cost fits a burst of 1000 to be read, got (%d, %v)", n, err)
+	}
+}
+
+// TestFitCost verifies that fitCost finds the largest size whose cost fits the limit.
+func TestFitCost(t *testing.T) {
+	square := func(n int) int { return n * n }
+	tests := []struct {
+		limit, n, want int
+	}{
+		{limit: 1_000, n: 100, want: 31},
+		{limit: 1_000, n: 10, want: 10},
+		{limit: 961, n: 100, want: 31},
+		{limit: 960, n: 100, want: 30},
+		{limit: 0, n: 100, want: 0},
+		{limit: 1_000, n: 0, want: 0},
+	}
+	for _, tt := range tests {
+		if got := fitCost(tt.limit, tt.n, square); got != tt.want {
+			t.Errorf("fitCost(%d, %d) = %d, want %d", tt.limit, tt.n, got, tt.want)
+		}
+	}
+}
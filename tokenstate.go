@@ -0,0 +1,52 @@
+package limitedlistener
+
+import (
+	"math"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// TokenState returns the current token level of the per-connection limiter, negative while earlier reads are
+// still being paid off and +Inf without a per-connection limit. Together with RestoreTokenState it lets a standby
+// taking over a connection continue where the limiter was, instead of starting with a full burst or a stall.
+// Unlike AvailableTokens it doesn't include the credit granted with GrantBurst.
+func (lc *LimitedConnection) TokenState() float64 {
+	limiter := lc.perConnLimiter()
+	if limiter.Limit() == rate.Inf {
+		return math.Inf(1)
+	}
+	return limiter.TokensAt(time.Now())
+}
+
+// RestoreTokenState sets the token level of the per-connection limiter to tokens, as returned by TokenState,
+// capped at the burst. It does nothing without a per-connection limit.
+func (lc *LimitedConnection) RestoreTokenState(tokens float64) {
+	limiter := lc.perConnLimiter()
+	if limiter.Limit() == rate.Inf || math.IsNaN(tokens) {
+		return
+	}
+
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	burst := limiter.Burst()
+	if burst < 1 {
+		return
+	}
+	tokens = min(tokens, float64(burst))
+
+	// The limiter can't be set to a level directly: tokens above the target are consumed with reservations, and
+	// the missing fraction is added by moving the time of the last update back, as if the tokens had accrued.
+	now := time.Now()
+	current := limiter.TokensAt(now)
+	limiter.SetBurstAt(now, burst)
+	for current > tokens {
+		n := min(int(math.Ceil(current-tokens)), burst)
+		limiter.ReserveN(now, n)
+		current -= float64(n)
+	}
+	if missing := tokens - current; missing > 0 {
+		limiter.SetBurstAt(now.Add(-time.Duration(missing/float64(limiter.Limit())*float64(time.Second))), burst)
+	}
+}
@@ -0,0 +1,66 @@
+package limitedlistener
+
+import (
+	"io"
+	"math"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestTokenState verifies that a snapshot of the token level can be restored after the limiter was drained, and
+// that a restored debt delays the next read.
+func TestTokenState(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 100_000, 1_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+	go func() {
+		chunk := make([]byte, 100)
+		for {
+			if _, err := client.Write(chunk); err != nil {
+				return
+			}
+		}
+	}()
+
+	snapshot := lc.TokenState()
+	if math.Abs(snapshot-1_000) > 1 {
+		t.Fatalf("expected a full bucket of 1000 tokens, got %.1f", snapshot)
+	}
+
+	lc.Limiter().AllowN(time.Now(), 1_000)
+	if got := lc.TokenState(); got > 10 {
+		t.Fatalf("expected the limiter to be drained, got %.1f tokens", got)
+	}
+
+	lc.RestoreTokenState(snapshot)
+	if got := lc.TokenState(); math.Abs(got-snapshot) > 10 {
+		t.Errorf("expected the restored level to be %.1f, got %.1f", snapshot, got)
+	}
+	start := time.Now()
+	if _, err := io.ReadFull(lc, make([]byte, 900)); err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("expected the restored tokens to grant the read right away, took %v", elapsed)
+	}
+
+	lc.RestoreTokenState(-500.5)
+	if got := lc.TokenState(); math.Abs(got+500.5) > 10 {
+		t.Errorf("expected the restored debt to be -500.5, got %.1f", got)
+	}
+	start = time.Now()
+	if _, err := lc.Read(make([]byte, 1)); err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 450*time.Millisecond {
+		t.Errorf("expected the restored debt to delay the next read by about 500ms, took %v", elapsed)
+	}
+}
@@ -0,0 +1,52 @@
+package limitedlistener
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// TopConnections returns the snapshots of the n connections with the highest recent throughput, in decreasing
+// order, to find the connections using most of the bandwidth. The throughput of a connection is the mean of its
+// samples under WithThroughputSampling, or its average since it was accepted without sampling or before the first
+// sample. The connections are ranked on a snapshot, without holding the listener lock.
+func (l *LimitedListener) TopConnections(n int) []ConnSnapshot {
+	if n <= 0 {
+		return nil
+	}
+
+	type ranked struct {
+		connection *LimitedConnection
+		throughput float64
+	}
+	connections := l.trackedConnections()
+	ranking := make([]ranked, 0, len(connections))
+	for _, connection := range connections {
+		ranking = append(ranking, ranked{connection, connection.recentThroughput()})
+	}
+	slices.SortFunc(ranking, func(a, b ranked) int {
+		return cmp.Compare(b.throughput, a.throughput)
+	})
+
+	snapshots := make([]ConnSnapshot, 0, min(n, len(ranking)))
+	for _, r := range ranking[:min(n, len(ranking))] {
+		snapshots = append(snapshots, r.connection.Snapshot())
+	}
+	return snapshots
+}
+
+// recentThroughput returns the recent throughput of the connection in bytes per second, read and written.
+func (lc *LimitedConnection) recentThroughput() float64 {
+	if samples := lc.RecentThroughput(); len(samples) > 0 {
+		var sum float64
+		for _, sample := range samples {
+			sum += sample
+		}
+		return sum / float64(len(samples))
+	}
+	age := time.Since(lc.createdAt).Seconds()
+	if age <= 0 {
+		return 0
+	}
+	return float64(lc.bytesRead.Load()+lc.bytesWritten.Load()) / age
+}
@@ -0,0 +1,67 @@
+package limitedlistener
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// TestTopConnections verifies that the connections are ranked by their recent throughput.
+func TestTopConnections(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 1_000_000, 1_000_000, WithThroughputSampling(50*time.Millisecond, 4))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer listener.Close()
+
+	// Each connection reads at its own per-connection rate; the idle one reads nothing.
+	rates := []int{2_000, 0, 20_000, 8_000}
+	ids := map[int]uint64{}
+	for _, rate := range rates {
+		server, client := net.Pipe()
+		defer client.Close()
+		lc := listener.Track(server)
+		defer lc.Close()
+		ids[rate] = lc.ID()
+		if rate == 0 {
+			continue
+		}
+		setConnRate(lc, rate)
+
+		go func() {
+			chunk := make([]byte, 100)
+			for {
+				if _, err := client.Write(chunk); err != nil {
+					return
+				}
+			}
+		}()
+		go func() {
+			buf := make([]byte, 100)
+			for {
+				if _, err := lc.Read(buf); err != nil {
+					return
+				}
+			}
+		}()
+	}
+
+	time.Sleep(400 * time.Millisecond)
+
+	top := listener.TopConnections(3)
+	if len(top) != 3 {
+		t.Fatalf("expected 3 connections, got %d", len(top))
+	}
+	for i, rate := range []int{20_000, 8_000, 2_000} {
+		if top[i].ID != ids[rate] {
+			t.Errorf("expected the connection at %d bytes per second at position %d, got connection %d", rate, i, top[i].ID)
+		}
+	}
+
+	if got := len(listener.TopConnections(10)); got != len(rates) {
+		t.Errorf("expected every connection when n exceeds their number, got %d", got)
+	}
+	if got := listener.TopConnections(0); got != nil {
+		t.Errorf("expected no connection for n = 0, got %v", got)
+	}
+}
@@ -0,0 +1,17 @@
+package limitedlistener
+
+import "net"
+
+// Track wraps a connection that did not come through Accept, for example one received over a Unix socket with fd
+// passing, and tracks it like an accepted connection: it gets an ID, the global limiters and the per-connection
+// limit of the listener, shows up in Stats and Connections and follows SetLimits. The accept filter, the admission
+// controller and the maximum number of connections are not applied.
+func (l *LimitedListener) Track(conn net.Conn) *LimitedConnection {
+	tenant, limited := l.classify(conn)
+	decayed := l.initialDecayedRate()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.trackLocked(conn, nil, tenant, limited, decayed)
+}
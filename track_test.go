@@ -0,0 +1,48 @@
+package limitedlistener
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestTrack verifies that a connection tracked from a side channel shows up in the stats and is throttled by the
+// listener's limits.
+func TestTrack(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 100_000, 1_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	server, client := net.Pipe()
+	defer client.Close()
+
+	lc := listener.Track(server)
+	if lc.ID() == 0 {
+		t.Errorf("expected the tracked connection to get an ID")
+	}
+	if stats := listener.Stats(); stats.ActiveConnections != 1 || stats.AcceptedConnections != 1 {
+		t.Errorf("expected the tracked connection in the stats, got %+v", stats)
+	}
+
+	go client.Write(make([]byte, 2_000))
+
+	start := time.Now()
+	if _, err := io.ReadFull(lc, make([]byte, 2_000)); err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("expected the tracked connection to be throttled to 1000 bytes per second, took %v", elapsed)
+	}
+
+	listener.SetLimits(100_000, 500)
+	if got := lc.Snapshot().PerConnLimit; got != 500 {
+		t.Errorf("expected the tracked connection to follow SetLimits, got %d", got)
+	}
+
+	lc.Close()
+	if stats := listener.Stats(); stats.ActiveConnections != 0 || stats.ClosedConnections != 1 {
+		t.Errorf("expected the closed connection to be removed, got %+v", stats)
+	}
+}
@@ -0,0 +1,81 @@
+package limitedlistener
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+var ErrWouldThrottle = fmt.Errorf("read would wait for bandwidth")
+
+// TryRead is a Read that never waits on the limiters, for event-loop servers that don't park a goroutine per
+// connection. The read is sized to the tokens currently available in every limiter of the connection and charged
+// without waiting; if no byte can be granted, the interval budget is spent or the listener is paused, it returns
+// (0, ErrWouldThrottle) without reading so the caller can come back later. The underlying read itself still blocks
+// until data is available, unless the connection has a deadline or is nonblocking.
+//
+// TryRead charges the global limiter directly, so it is not scheduled by WithRoundRobinScheduling.
+func (lc *LimitedConnection) TryRead(b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	budget, _ := lc.remainingBudget(time.Now())
+	if lc.paused() || budget == 0 {
+		return 0, ErrWouldThrottle
+	}
+	if _, ok := lc.warmupRemaining(); ok || lc.measureOnly() {
+		return lc.Read(b)
+	}
+
+	done, err := lc.enterRead()
+	if err != nil {
+		return 0, err
+	}
+	defer done()
+
+	addSaturating(&lc.bytesRequested, int64(len(b)))
+	if err := lc.checkConnQuota(); err != nil {
+		return 0, err
+	}
+
+	var buf [4]*rate.Limiter
+	limiters := lc.limiters(buf[:0])
+
+	opts := lc.options()
+	allowed := int(min(int64(len(b)), budget))
+	now := time.Now()
+	for i, limiter := range limiters {
+		if limiter.Limit() == rate.Inf {
+			continue
+		}
+		tokens := limiter.TokensAt(now)
+		if i == 1 {
+			tokens += float64(lc.burstCredit.Load())
+		}
+		allowed = opts.fitRead(int(tokens), allowed)
+	}
+	if allowed < 1 {
+		return 0, ErrWouldThrottle
+	}
+
+	n, err := lc.readConn(b[:allowed])
+	if n <= 0 {
+		return n, err
+	}
+	lc.recordRead(n)
+	lc.observeRead(b[:n])
+
+	// The tokens were available when the read was sized, so the reservations only go into debt if another read
+	// consumed them in the meantime; the connection then pays it off on its next reads like with Read.
+	charge := opts.readTokens(n)
+	now = time.Now()
+	for i, limiter := range limiters {
+		if i == 1 {
+			limiter.ReserveN(now, charge-lc.takeBurstCredit(charge))
+			continue
+		}
+		limiter.ReserveN(now, charge)
+	}
+	return n, err
+}
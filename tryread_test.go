@@ -0,0 +1,49 @@
+package limitedlistener
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestTryRead verifies that TryRead returns ErrWouldThrottle without reading once the tokens are drained,
+// and reads again after they refilled.
+func TestTryRead(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 10_000, 1_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	go client.Write(make([]byte, 1_500))
+
+	if n, err := lc.TryRead(make([]byte, 1_500)); n != 1_000 || err != nil {
+		t.Fatalf("expected the burst of 1000 bytes to be read, got (%d, %v)", n, err)
+	}
+
+	start := time.Now()
+	if n, err := lc.TryRead(make([]byte, 500)); n != 0 || !errors.Is(err, ErrWouldThrottle) {
+		t.Errorf("expected (0, ErrWouldThrottle) with the tokens drained, got (%d, %v)", n, err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("expected TryRead to return right away, but it took %v", elapsed)
+	}
+	if got := lc.BytesRead(); got != 1_000 {
+		t.Errorf("expected the throttled TryRead not to read, got %d bytes read", got)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	n, err := lc.TryRead(make([]byte, 500))
+	if err != nil {
+		t.Fatalf("expected TryRead to succeed after the refill, got %v", err)
+	}
+	if n < 150 || n > 300 {
+		t.Errorf("expected the read to be sized to the refilled tokens, got %d bytes", n)
+	}
+}
@@ -0,0 +1,15 @@
+package limitedlistener
+
+// WithoutConnectionTracking stops the listener from keeping track of the connections it accepts, saving the
+// bookkeeping of every accept and close at high connection churn when only the global limits matter. The
+// connections are still limited by the global and per-connection limits they were accepted with.
+//
+// Everything that walks the tracked connections sees none in this mode: Connections, TopConnections and the
+// active connection count in Stats, SetLimits and the other limit changes for existing connections, Shutdown,
+// WithMaxConnections, WithOnIdle, WithTenant, Release and the options derived from the number of active
+// connections. Only the accepted and closed counters keep working.
+func WithoutConnectionTracking() Option {
+	return func(o *options) {
+		o.untracked = true
+	}
+}
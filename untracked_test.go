@@ -0,0 +1,65 @@
+package limitedlistener
+
+import (
+	"testing"
+	"time"
+)
+
+// TestWithoutConnectionTracking verifies that untracked connections are still limited and counted but not tracked.
+func TestWithoutConnectionTracking(t *testing.T) {
+	listener, err := NewLimitedListener(zeroListener{}, 10_000, 10_000, WithoutConnectionTracking())
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	if got := len(listener.Connections()); got != 0 {
+		t.Errorf("expected no tracked connection, got %d", got)
+	}
+
+	listener.GlobalLimiter().ReserveN(time.Now(), 10_000)
+	start := time.Now()
+	if _, err := conn.Read(make([]byte, 5_000)); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("expected the global limit to still apply, read took %v", elapsed)
+	}
+
+	conn.Close()
+	if stats := listener.Stats(); stats.AcceptedConnections != 1 || stats.ClosedConnections != 1 {
+		t.Errorf("expected 1 accepted and 1 closed connection, got %d and %d", stats.AcceptedConnections, stats.ClosedConnections)
+	}
+}
+
+// BenchmarkAcceptClose compares the cost of accepting and closing a connection with and without connection tracking.
+func BenchmarkAcceptClose(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"tracked", nil},
+		{"untracked", []Option{WithoutConnectionTracking()}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			listener, err := NewLimitedListener(zeroListener{}, 1_000_000, 1_000, bc.opts...)
+			if err != nil {
+				b.Fatalf("didn't expect error but got one: %v", err)
+			}
+
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					conn, err := listener.Accept()
+					if err != nil {
+						b.Fatalf("didn't expect error but got one: %v", err)
+					}
+					conn.Close()
+				}
+			})
+		})
+	}
+}
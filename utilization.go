@@ -0,0 +1,43 @@
+package limitedlistener
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// utilizationWindow is the shortest window over which GlobalUtilization measures the throughput.
+const utilizationWindow = 250 * time.Millisecond
+
+// utilizationSampler remembers the read byte counter of the listener at the last GlobalUtilization sample.
+type utilizationSampler struct {
+	mu    sync.Mutex
+	at    time.Time
+	bytes int64
+	rate  float64
+}
+
+// GlobalUtilization returns the aggregate read throughput of the listener divided by its global limit. Values near
+// or above 1 mean the global limit is saturated; it returns 0 when there is no global limit.
+//
+// The throughput is measured between calls, over windows of at least 250ms: a call within 250ms of the sample
+// taken by a previous call returns the same value. The first window starts when the listener is created.
+func (l *LimitedListener) GlobalUtilization() float64 {
+	limit := l.globalReadLimiter.Limit()
+	if limit == rate.Inf || limit <= 0 {
+		return 0
+	}
+
+	now, bytes := time.Now(), l.bytesRead.Load()
+
+	s := &l.utilization
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elapsed := now.Sub(s.at); elapsed >= utilizationWindow {
+		s.rate = float64(bytes-s.bytes) / elapsed.Seconds()
+		s.at, s.bytes = now, bytes
+	}
+	return s.rate / float64(limit)
+}
@@ -0,0 +1,64 @@
+package limitedlistener
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// TestGlobalUtilization verifies that the utilization is near zero while idle and rises toward 1 once traffic is
+// bound by the global limit.
+func TestGlobalUtilization(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 20_000, 20_000)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	if got := listener.GlobalUtilization(); got != 0 {
+		t.Errorf("expected no utilization while idle, got %.2f", got)
+	}
+
+	server, client := net.Pipe()
+	defer client.Close()
+	lc, err := listener.admit(server)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer lc.Close()
+
+	go func() {
+		chunk := make([]byte, 1_000)
+		for {
+			if _, err := client.Write(chunk); err != nil {
+				return
+			}
+		}
+	}()
+	go func() {
+		buf := make([]byte, 1_000)
+		for {
+			if _, err := lc.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+
+	// The first window includes the initial burst; the following ones are bound by the limit.
+	var got float64
+	for i := 0; i < 4; i++ {
+		time.Sleep(300 * time.Millisecond)
+		got = listener.GlobalUtilization()
+	}
+	if got < 0.8 || got > 1.2 {
+		t.Errorf("expected the utilization to approach 1 while the global limit binds, got %.2f", got)
+	}
+
+	unlimited, err := newLimitedListener(nil, newOptions(nil))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	if got := unlimited.GlobalUtilization(); got != 0 {
+		t.Errorf("expected no utilization without a global limit, got %.2f", got)
+	}
+}
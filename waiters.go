@@ -0,0 +1,38 @@
+package limitedlistener
+
+import "fmt"
+
+var ErrTooManyWaiters = fmt.Errorf("too many reads waiting for bandwidth")
+
+// WithMaxBlockedWaiters bounds the number of reads blocked waiting for limiter tokens across the listener, so that
+// under heavy congestion goroutines and their buffers don't pile up behind the limiters. A Read started while n
+// reads are already waiting returns (0, ErrTooManyWaiters) right away, before touching the underlying connection.
+// The check is made when the Read starts, so reads already past it can briefly bring the count above n.
+func WithMaxBlockedWaiters(n int) Option {
+	return func(o *options) {
+		o.maxBlockedWaiters = n
+	}
+}
+
+// checkWaiters returns ErrTooManyWaiters if the listener owning the connection already has its maximum number of
+// reads waiting for tokens.
+func (lc *LimitedConnection) checkWaiters() error {
+	parent := lc.parentListener.Load()
+	if parent == nil || parent.opts.maxBlockedWaiters <= 0 {
+		return nil
+	}
+	if parent.blockedWaiters.Load() >= int64(parent.opts.maxBlockedWaiters) {
+		return ErrTooManyWaiters
+	}
+	return nil
+}
+
+// trackWaiter counts the connection as waiting for tokens, returning the function ending the wait.
+func (lc *LimitedConnection) trackWaiter() func() {
+	parent := lc.parentListener.Load()
+	if parent == nil || parent.opts.maxBlockedWaiters <= 0 {
+		return func() {}
+	}
+	parent.blockedWaiters.Add(1)
+	return func() { parent.blockedWaiters.Add(-1) }
+}
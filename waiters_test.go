@@ -0,0 +1,70 @@
+package limitedlistener
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestMaxBlockedWaiters verifies that reads are rejected with ErrTooManyWaiters once the maximum number of reads
+// is blocked on a saturated limiter, and accepted again once they drain.
+func TestMaxBlockedWaiters(t *testing.T) {
+	const maxWaiters = 3
+
+	listener, err := NewLimitedListener(nil, 1_000, 1_000, WithMaxBlockedWaiters(maxWaiters))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	// Saturate the global limiter, so every read waits for about a second.
+	now := time.Now()
+	listener.globalReadLimiter.AllowN(now, 1_000)
+	listener.globalReadLimiter.ReserveN(now, 1_000)
+
+	newConn := func() (*LimitedConnection, net.Conn) {
+		server, client := net.Pipe()
+		t.Cleanup(func() { client.Close() })
+		lc := admitConn(t, listener, server)
+		t.Cleanup(func() { lc.Close() })
+		return lc, client
+	}
+
+	done := make(chan error, maxWaiters)
+	for i := 0; i < maxWaiters; i++ {
+		lc, client := newConn()
+		go client.Write(make([]byte, 10))
+		go func() {
+			_, err := lc.Read(make([]byte, 10))
+			done <- err
+		}()
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for listener.blockedWaiters.Load() < maxWaiters {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d reads to be blocked, got %d", maxWaiters, listener.blockedWaiters.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	lc, client := newConn()
+	start := time.Now()
+	if _, err := lc.Read(make([]byte, 10)); !errors.Is(err, ErrTooManyWaiters) {
+		t.Errorf("expected ErrTooManyWaiters, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("expected the read to be rejected right away, but it took %v", elapsed)
+	}
+
+	for i := 0; i < maxWaiters; i++ {
+		if err := <-done; err != nil {
+			t.Errorf("expected the blocked reads to complete, got %v", err)
+		}
+	}
+
+	go client.Write(make([]byte, 10))
+	if _, err := lc.Read(make([]byte, 10)); err != nil {
+		t.Errorf("expected reads to be accepted once the waiters drained, got %v", err)
+	}
+}
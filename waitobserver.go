@@ -0,0 +1,37 @@
+package limitedlistener
+
+import (
+	"context"
+	"time"
+)
+
+// blockingWaitThreshold is the shortest limiter wait reported to the wait observer. Shorter waits are the cost
+// of the limiter bookkeeping when tokens are available, not throttling.
+const blockingWaitThreshold = 100 * time.Microsecond
+
+// WaitEvent describes a read or write that was held back by the limiters.
+type WaitEvent struct {
+	Conn  *LimitedConnection
+	Op    string // "read" or "write"
+	Bytes int
+	Wait  time.Duration
+}
+
+// WithWaitObserver calls observe after every Read or Write that blocked on the limiters, with the context the
+// wait was made with, for example to record throttling in traces or metrics. Transfers that did not block are
+// not reported, so the observer adds no cost to the fast path. It is called synchronously and must not block.
+func WithWaitObserver(observe func(ctx context.Context, event WaitEvent)) Option {
+	return func(o *options) {
+		o.waitObserver = observe
+	}
+}
+
+// observeWait reports a wait of the connection to the wait observer if it blocked.
+func (lc *LimitedConnection) observeWait(ctx context.Context, op string, bytes int, wait time.Duration) {
+	if wait < blockingWaitThreshold {
+		return
+	}
+	if observe := lc.options().waitObserver; observe != nil {
+		observe(ctx, WaitEvent{Conn: lc, Op: op, Bytes: bytes, Wait: wait})
+	}
+}
@@ -0,0 +1,46 @@
+package limitedlistener
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+)
+
+// TestWaitObserver verifies that only the writes that blocked on the limiter are reported.
+func TestWaitObserver(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+	go io.Copy(io.Discard, client)
+
+	var mu sync.Mutex
+	var events []WaitEvent
+	listener, err := NewLimitedListener(nil, 1_000, 1_000, WithGlobalWriteLimit(1_000), WithWaitObserver(func(_ context.Context, event WaitEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	if _, err := lc.Write(make([]byte, 1_000)); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if _, err := lc.Write(make([]byte, 300)); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 1 {
+		t.Fatalf("expected only the throttled write to be reported, got %d events", len(events))
+	}
+	if got := events[0]; got.Conn != lc || got.Op != "write" || got.Bytes != 300 || got.Wait < blockingWaitThreshold {
+		t.Errorf("unexpected event %+v", got)
+	}
+}
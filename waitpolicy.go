@@ -0,0 +1,59 @@
+package limitedlistener
+
+import (
+	"context"
+	"time"
+)
+
+// WaitErrorPolicy decides what a read or write does when waiting on a limiter fails, for example because the
+// requested tokens exceed a burst lowered to zero through GlobalLimiter.
+type WaitErrorPolicy int
+
+const (
+	// PolicyReturnError returns the wait error to the caller, along with the bytes already read. It is the default.
+	PolicyReturnError WaitErrorPolicy = iota
+	// PolicyCloseConn closes the connection and returns the wait error.
+	PolicyCloseConn
+	// PolicyRetry retries the wait until it succeeds, the connection is closed or the listener's context is
+	// cancelled. Bytes already read are kept and returned once the wait succeeds.
+	PolicyRetry
+)
+
+// waitRetryInterval is the pause between two attempts of a wait under PolicyRetry.
+const waitRetryInterval = 10 * time.Millisecond
+
+// WithWaitErrorPolicy sets how reads and writes react to a failed limiter wait.
+func WithWaitErrorPolicy(policy WaitErrorPolicy) Option {
+	return func(o *options) {
+		o.waitErrorPolicy = policy
+	}
+}
+
+// withWaitPolicy runs wait and applies the wait error policy of the connection if it fails.
+func (lc *LimitedConnection) withWaitPolicy(ctx context.Context, wait func() error) error {
+	err := wait()
+	if err == nil {
+		return nil
+	}
+
+	switch lc.options().waitErrorPolicy {
+	case PolicyCloseConn:
+		lc.Close()
+	case PolicyRetry:
+		timer := time.NewTimer(waitRetryInterval)
+		defer timer.Stop()
+		for err != nil {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-lc.closing:
+				return err
+			case <-timer.C:
+			}
+			if err = wait(); err != nil {
+				timer.Reset(waitRetryInterval)
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,74 @@
+package limitedlistener
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestWaitErrorPolicy verifies the effect of each policy on a read whose global wait fails because the global
+// burst was lowered to zero.
+func TestWaitErrorPolicy(t *testing.T) {
+	setup := func(policy WaitErrorPolicy) (*LimitedListener, *LimitedConnection, net.Conn) {
+		server, client := net.Pipe()
+		listener, err := NewLimitedListener(nil, 1_000, 1_000, WithWaitErrorPolicy(policy))
+		if err != nil {
+			t.Fatalf("didn't expect error but got one: %v", err)
+		}
+		lc := admitConn(t, listener, server)
+		lc.perConnLimiter()
+		listener.GlobalLimiter().SetBurst(0)
+		go client.Write([]byte{0})
+		return listener, lc, client
+	}
+
+	t.Run("return error", func(t *testing.T) {
+		_, lc, client := setup(PolicyReturnError)
+		defer client.Close()
+		defer lc.Close()
+
+		n, err := lc.Read(make([]byte, 10))
+		if n != 1 || err == nil {
+			t.Errorf("expected the bytes read along with the wait error, got (%d, %v)", n, err)
+		}
+		client.SetWriteDeadline(time.Now().Add(50 * time.Millisecond))
+		if _, err := client.Write([]byte{0}); !errors.Is(err, os.ErrDeadlineExceeded) {
+			t.Errorf("expected the connection to stay open, got %v", err)
+		}
+	})
+
+	t.Run("close conn", func(t *testing.T) {
+		_, lc, client := setup(PolicyCloseConn)
+		defer client.Close()
+
+		if _, err := lc.Read(make([]byte, 10)); err == nil {
+			t.Errorf("expected the wait error")
+		}
+		client.SetWriteDeadline(time.Now().Add(50 * time.Millisecond))
+		if _, err := client.Write([]byte{0}); !errors.Is(err, io.ErrClosedPipe) {
+			t.Errorf("expected the connection to be closed, got %v", err)
+		}
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		listener, lc, client := setup(PolicyRetry)
+		defer client.Close()
+		defer lc.Close()
+
+		time.AfterFunc(50*time.Millisecond, func() {
+			listener.GlobalLimiter().SetBurst(1_000)
+		})
+
+		start := time.Now()
+		n, err := lc.Read(make([]byte, 10))
+		if n != 1 || err != nil {
+			t.Errorf("expected the read to succeed once the burst was restored, got (%d, %v)", n, err)
+		}
+		if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+			t.Errorf("expected the read to retry until the burst was restored, returned after %v", elapsed)
+		}
+	})
+}
@@ -0,0 +1,33 @@
+package limitedlistener
+
+import (
+	"math"
+	"time"
+)
+
+// WithWarmup lets every connection transfer unthrottled until it has transferred bytes bytes or is older than
+// duration, whichever comes first, so TLS handshakes and small requests complete quickly before the limits
+// engage for the bulk of the transfer. A zero value disables the corresponding threshold.
+func WithWarmup(bytes int64, duration time.Duration) Option {
+	return func(o *options) {
+		o.warmupBytes = bytes
+		o.warmupDuration = duration
+	}
+}
+
+// warmupRemaining reports whether the connection is still warming up and how many bytes it may still transfer unthrottled.
+func (lc *LimitedConnection) warmupRemaining() (int64, bool) {
+	o := lc.options()
+	if o.warmupBytes <= 0 && o.warmupDuration <= 0 {
+		return 0, false
+	}
+	if o.warmupDuration > 0 && time.Since(lc.createdAt) >= o.warmupDuration {
+		return 0, false
+	}
+	if o.warmupBytes <= 0 {
+		return math.MaxInt64, true
+	}
+
+	remaining := o.warmupBytes - lc.bytesRead.Load() - lc.bytesWritten.Load()
+	return remaining, remaining > 0
+}
@@ -0,0 +1,120 @@
+package limitedlistener
+
+import (
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestWarmup verifies that the first bytes of a connection transfer unthrottled and the following ones obey the limit.
+func TestWarmup(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 1_000, 1_000, WithWarmup(10_000, time.Minute))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	go client.Write(make([]byte, 11_500))
+
+	start := time.Now()
+	if _, err := io.ReadFull(lc, make([]byte, 10_000)); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("expected the warmup bytes to transfer unthrottled, but it took %v", elapsed)
+	}
+
+	start = time.Now()
+	if _, err := io.ReadFull(lc, make([]byte, 1_500)); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 450*time.Millisecond {
+		t.Errorf("expected the bytes after the warmup to be throttled, but it took %v", elapsed)
+	}
+}
+
+// TestWarmupDuration verifies that the limits engage once the warmup duration has passed.
+func TestWarmupDuration(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 1_000, 1_000, WithWarmup(0, 50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, nil)
+	if _, ok := lc.warmupRemaining(); !ok {
+		t.Errorf("expected a new connection to be warming up")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if _, ok := lc.warmupRemaining(); ok {
+		t.Errorf("expected the warmup to end after its duration")
+	}
+}
+
+// TestWarmupPause verifies that Pause halts reads and writes of connections that are still warming up.
+func TestWarmupPause(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 1_000, 1_000, WithWarmup(0, time.Minute))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	go func() {
+		chunk := make([]byte, 1_000)
+		for {
+			if _, err := client.Write(chunk); err != nil {
+				return
+			}
+		}
+	}()
+
+	var received atomic.Int64
+	go func() {
+		buf := make([]byte, 1_000)
+		for {
+			n, err := lc.Read(buf)
+			received.Add(int64(n))
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	listener.Pause()
+	time.Sleep(20 * time.Millisecond)
+	halted := received.Load()
+	time.Sleep(100 * time.Millisecond)
+	if got := received.Load(); got != halted {
+		t.Errorf("expected no data read while paused, but %d bytes were received", got-halted)
+	}
+
+	written := make(chan struct{})
+	go func() {
+		lc.Write([]byte("ping"))
+		close(written)
+	}()
+	select {
+	case <-written:
+		t.Errorf("expected the write to wait while paused")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	listener.Resume()
+	time.Sleep(50 * time.Millisecond)
+	if got := received.Load(); got == halted {
+		t.Errorf("expected the transfer to resume")
+	}
+}
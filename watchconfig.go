@@ -0,0 +1,53 @@
+package limitedlistener
+
+import (
+	"log"
+)
+
+// WatchConfig starts a goroutine applying every Config received from ch, in order, until ch is closed or the
+// listener is shut down. A received configuration is validated first and then applied as a whole, serialized
+// with SetLimits, so the connections never see half of it. An invalid configuration is logged and skipped,
+// leaving the limits unchanged.
+func (l *LimitedListener) WatchConfig(ch <-chan Config) {
+	go func() {
+		for {
+			select {
+			case config, ok := <-ch:
+				if !ok {
+					return
+				}
+				if err := l.applyConfig(config); err != nil {
+					log.Printf("limitedlistener: skipping config %+v: %v", config, err)
+				}
+			case <-l.done:
+				return
+			}
+		}
+	}()
+}
+
+// applyConfig validates config and applies it. A zero burst keeps the default burst of the global limit.
+func (l *LimitedListener) applyConfig(config Config) error {
+	perConn := l.perConnFor(config.GlobalLimit, config.PerConnLimit)
+	if config.GlobalLimit < 0 || perConn < 0 || config.GlobalBurst < 0 || config.GlobalWriteLimit < 0 {
+		return ErrLimitOutOfRange
+	}
+	if config.GlobalLimit > 0 && perConn > 0 {
+		if err := validateLimits(config.GlobalLimit, perConn); err != nil {
+			return err
+		}
+	}
+
+	l.setLimitsMu.Lock()
+	defer l.setLimitsMu.Unlock()
+
+	l.applyLimits(config.GlobalLimit, perConn)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if config.GlobalBurst > 0 && config.GlobalLimit > 0 {
+		l.globalReadLimiter.SetBurst(config.GlobalBurst)
+	}
+	setLimiterRate(l.globalWriteLimiter, config.GlobalWriteLimit)
+	return nil
+}
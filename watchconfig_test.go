@@ -0,0 +1,56 @@
+package limitedlistener
+
+import (
+	"testing"
+	"time"
+)
+
+// TestWatchConfig verifies that the configurations received from the channel are applied in order and that an
+// invalid one is skipped.
+func TestWatchConfig(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 1_000, 100)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+	defer listener.Shutdown()
+
+	ch := make(chan Config)
+	listener.WatchConfig(ch)
+
+	first := Config{GlobalLimit: 2_000, GlobalBurst: 4_000, PerConnLimit: 200, GlobalWriteLimit: 500}
+	ch <- first
+	waitForLimits(t, listener, 2_000, 200)
+	if got := listener.ExportConfig(); got != first {
+		t.Errorf("expected %+v, but got %+v", first, got)
+	}
+
+	ch <- Config{GlobalLimit: 100, PerConnLimit: 200}
+
+	second := Config{GlobalLimit: 3_000, GlobalBurst: 3_000, PerConnLimit: 300}
+	ch <- second
+	waitForLimits(t, listener, 3_000, 300)
+	if got := listener.ExportConfig(); got != second {
+		t.Errorf("expected %+v, but got %+v", second, got)
+	}
+	close(ch)
+}
+
+// TestWatchConfigShutdown verifies that the watcher stops once the listener is shut down.
+func TestWatchConfigShutdown(t *testing.T) {
+	listener, err := NewLimitedListener(nil, 1_000, 100)
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	ch := make(chan Config)
+	listener.WatchConfig(ch)
+	listener.Shutdown()
+	// Nothing is pending on ch yet, so the watcher can only observe the shutdown.
+	time.Sleep(20 * time.Millisecond)
+
+	select {
+	case ch <- Config{GlobalLimit: 2_000, PerConnLimit: 200}:
+		t.Fatalf("expected the watcher to stop after Shutdown")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,39 @@
+package limitedlistener
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// WithWorkConserving lets a connection exceed its per-connection limit, up to perConnCeiling bytes per second,
+// while the global limiter has idle capacity. With few active connections they can then use the global
+// bandwidth instead of leaving it unused; once the global budget is contended the per-connection limit binds again.
+func WithWorkConserving(perConnCeiling int) Option {
+	return func(o *options) {
+		o.workConservingCeiling = perConnCeiling
+	}
+}
+
+// waitPerConn charges n bytes to the per-connection limiter. In work-conserving mode the connection is always
+// bounded by its ceiling limiter, but borrows idle global capacity instead of waiting on its per-connection limiter.
+func (lc *LimitedConnection) waitPerConn(ctx context.Context, limiter, globalLimiter *rate.Limiter, n int) error {
+	ceiling := lc.ceilingLimiter.Load()
+	if ceiling == nil {
+		return waitN(ctx, limiter, n)
+	}
+
+	if err := waitN(ctx, ceiling, n); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if limiter.AllowN(now, n) {
+		return nil
+	}
+	if globalLimiter.Limit() == rate.Inf || globalLimiter.TokensAt(now) >= float64(n) {
+		return nil
+	}
+	return waitN(ctx, limiter, n)
+}
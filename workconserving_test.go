@@ -0,0 +1,38 @@
+package limitedlistener
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestWorkConserving verifies that a single active connection borrows idle global capacity up to its ceiling.
+func TestWorkConserving(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	listener, err := NewLimitedListener(nil, 10_000, 1_000, WithWorkConserving(5_000))
+	if err != nil {
+		t.Fatalf("didn't expect error but got one: %v", err)
+	}
+
+	lc := admitConn(t, listener, server)
+	defer lc.Close()
+
+	go client.Write(make([]byte, 7_500))
+
+	start := time.Now()
+	if _, err := io.ReadFull(lc, make([]byte, 7_500)); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	// At the base per-connection limit the transfer would take 6.5 seconds, at the ceiling half a second.
+	if elapsed > 1500*time.Millisecond {
+		t.Errorf("expected the connection to exceed its per-connection limit, but the transfer took %v", elapsed)
+	}
+	if elapsed < 450*time.Millisecond {
+		t.Errorf("expected the connection to be bounded by its ceiling, but the transfer took %v", elapsed)
+	}
+}